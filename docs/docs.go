@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/begin-task-for-user": {
             "post": {
-                "description": "Begin task for user",
+                "description": "Begin task for user, a todo or paused task moves to in_progress. Done and cancelled tasks can't be begun",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,6 +46,14 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    {
+                        "description": "Note for the time entry",
+                        "name": "note",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
@@ -61,6 +69,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Задача уже начата, у пользователя идет таймер по другой задаче или задача завершена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -115,10 +129,66 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список счетов (costs)",
+                        "description": "Время по задачам (costs), по убыванию",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/timetracking.TaskCost"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/current-timer": {
+            "get": {
+                "description": "Get the running timer of the user (null if the user is not working now) and the paused timers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Get current timer of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passport series",
+                        "name": "pasportSeries",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.CurrentTimers"
                         }
                     },
                     "400": {
@@ -138,7 +208,7 @@ const docTemplate = `{
         },
         "/end-task-for-user": {
             "post": {
-                "description": "Finish tracking time for a specific task",
+                "description": "Finish tracking time for a specific task, the task moves from in_progress to paused when its last timer stops",
                 "produces": [
                     "application/json"
                 ],
@@ -161,6 +231,14 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    {
+                        "description": "Note for the time entry",
+                        "name": "note",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
@@ -176,6 +254,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Задача не начата пользователем",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -236,9 +320,60 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/invoice": {
+            "get": {
+                "description": "Get invoice with its lines by id, as JSON or as an HTML page for printing (format=html or Accept: text/html).\nPDF is not supported: format=pdf is rejected, print the HTML page to PDF in the browser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "Invoice"
+                ],
+                "summary": "Get invoice",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Invoice ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default) or html, pdf is not supported",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/invoices": {
             "get": {
-                "description": "Get users data by filter and pagination",
+                "description": "Get invoices without lines by filter, sort and pagination",
                 "consumes": [
                     "application/json"
                 ],
@@ -246,16 +381,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Invoice"
                 ],
-                "summary": "Get users data by filter and pagination",
+                "summary": "Get invoices by filter and pagination",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter",
+                        "description": "Filter: field=value or field__op=value joined by \u0026\u0026, op: eq, neq, gt, gte, lt, lte, in, like, isnull",
                         "name": "filter",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, number, client, project_id, period_from, period_to, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Limit",
@@ -267,15 +408,24 @@ const docTemplate = `{
                         "description": "Offset",
                         "name": "offset",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset",
+                        "name": "after",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/timetracking.User"
+                            "$ref": "#/definitions/timetracking.InvoicesPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на следующую и предыдущую страницы (RFC 8288)"
                             }
                         }
                     },
@@ -293,8 +443,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Update user data by passport series and number",
+            "post": {
+                "description": "Create a numbered invoice from unbilled billable time of the client's projects (or one project) started in the period.\nOnly ended time entries are invoiced, every billable entry must have an hourly rate, all time must be in one currency.\nLines are per task or per user and rate. Invoiced time entries can't be edited or deleted",
                 "consumes": [
                     "application/json"
                 ],
@@ -302,31 +452,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Invoice"
                 ],
-                "summary": "Update user data",
+                "summary": "Create invoice",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Passport series",
-                        "name": "pasportSeries",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Passport number",
-                        "name": "pasportNumber",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "User data",
+                        "description": "Client or project, period and grouping of lines",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/timetracking.User"
+                            "$ref": "#/definitions/timetracking.InvoiceParams"
                         }
                     }
                 ],
@@ -334,7 +470,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "int32"
                         }
                     },
                     "400": {
@@ -343,6 +479,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Номер счета занят параллельно созданным счетом, нужно повторить запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -350,9 +492,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/pause-task-for-user": {
             "post": {
-                "description": "Create user with passport data",
+                "description": "Pause tracking time for a started task, the work session stays open",
                 "consumes": [
                     "application/json"
                 ],
@@ -360,17 +504,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Time Tracking"
                 ],
-                "summary": "Create user",
+                "summary": "Pause task time tracking",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "body",
+                        "description": "Passport number",
+                        "name": "pasportNumber",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/timetracking.User"
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 ],
@@ -378,7 +531,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "int32"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -387,6 +540,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Задача не начата или уже приостановлена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -394,9 +553,43 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete user by passport series and number",
+            }
+        },
+        "/pool-stats": {
+            "get": {
+                "description": "Get connection pool statistics of the storage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Service"
+                ],
+                "summary": "Storage connection pool statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.PoolStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Хранилище без пула соединений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/project": {
+            "get": {
+                "description": "Get project by id with task count and time spent on its tasks",
                 "consumes": [
                     "application/json"
                 ],
@@ -404,21 +597,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Project"
                 ],
-                "summary": "Delete user",
+                "summary": "Get project",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Passport series",
-                        "name": "pasportSeries",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Passport number",
-                        "name": "pasportNumber",
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
                         "in": "query",
                         "required": true
                     }
@@ -427,7 +613,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/timetracking.Project"
                         }
                     },
                     "400": {
@@ -444,27 +630,2716 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "timetracking.User": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "description": "адрес",
-                    "type": "string"
-                },
-                "name": {
-                    "description": "имя",
-                    "type": "string"
-                },
-                "patronymic": {
-                    "description": "отчество",
-                    "type": "string"
-                },
-                "surname": {
-                    "description": "фамилия",
-                    "type": "string"
+        },
+        "/projects": {
+            "get": {
+                "description": "Get projects by filter, sort and pagination with task count and time spent on their tasks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Project"
+                ],
+                "summary": "Get projects by filter and pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter: field=value or field__op=value joined by \u0026\u0026, op: eq, neq, gt, gte, lt, lte, in, like, isnull",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, name, code, client, period_from, period_to, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active (true) or inactive (false) projects",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.ProjectsPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update given fields of the project, tasks can't be added to an inactive project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Project"
+                ],
+                "summary": "Update project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Project data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.ProjectData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Код проекта занят",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create project, name (up to 100 characters) and unique code (up to 20 characters, no spaces) are required, the project is active and billable by default, currency - ISO 4217 code of its rates (default currency if empty)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Project"
+                ],
+                "summary": "Create project",
+                "parameters": [
+                    {
+                        "description": "Project data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.ProjectData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "int32"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Код проекта занят",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete project, a project with tasks can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Project"
+                ],
+                "summary": "Delete project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "get": {
+                "description": "Time spent by days, weeks or months grouped by tasks, users or projects, with totals. Periods without time are included.\nBillable time is priced by the task rate, else the project rate, else the user rate; amounts are per currency.\nCSV and XLSX have a line per task, user or project in a period (kind=row), per period (subtotal) and per report (total)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Time report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day of the report (YYYY-MM-DD)",
+                        "name": "periodFrom",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day of the report, inclusive (YYYY-MM-DD)",
+                        "name": "periodTo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period: day (default), week (from Monday) or month",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Grouping: task (default), user or project (tasks without project - id 0)",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only time of the user: passport series",
+                        "name": "pasportSeries",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only time of the user: passport number",
+                        "name": "pasportNumber",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only time of the task",
+                        "name": "taskId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only time of the tasks of the project",
+                        "name": "projectId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of day boundaries (IANA name, e.g. Europe/Moscow), default - time zone of the -workday-tz flag",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default), csv or xlsx, also chosen by the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV/XLSX columns in order: key or key:Header, comma-separated (kind, start, end, id, name, seconds, duration)",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/resume-task-for-user": {
+            "post": {
+                "description": "Resume tracking time for a paused task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Resume task time tracking",
+                "parameters": [
+                    {
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Задача не приостановлена или у пользователя идет таймер по другой задаче",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task": {
+            "get": {
+                "description": "Get task by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-assignments": {
+            "post": {
+                "description": "Assign user to task, several users can work on one task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Assign user to task",
+                "parameters": [
+                    {
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unassign user from task, user's time entries are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Unassign user from task",
+                "parameters": [
+                    {
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь начал задачу",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-estimates": {
+            "get": {
+                "description": "Estimate, actual time (including running timers) and remaining effort of tasks, with totals.\nA task is overdue when its periodTo has passed and it isn't done or cancelled, overrun when its actual time exceeds the estimate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Task estimates versus actuals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter of tasks as in GET /tasks",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, title, project_id, period_from, period_to, cost, estimate, status, completed_at, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only tasks of the project",
+                        "name": "projectId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only overdue (true) or not overdue (false) tasks",
+                        "name": "overdue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only tasks over (true) or within (false) the estimate",
+                        "name": "overrun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.EstimateReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-status": {
+            "post": {
+                "description": "Move the task to another status: todo -\u003e in_progress, done, cancelled; in_progress -\u003e paused, done, cancelled;\npaused -\u003e in_progress, done, cancelled; done -\u003e in_progress; cancelled -\u003e todo.\nA task with started time entries can't be paused, done or cancelled. Time isn't tracked on done and cancelled tasks\nBeginning a task moves it to in_progress, stopping its last timer moves it from in_progress to paused",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Change task status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "New status and note",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TaskStatusData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Переход из текущего статуса недопустим или задача начата",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-status-history": {
+            "get": {
+                "description": "Status changes of the task in order, the first one is the creation of the task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get task status history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История статусов (history)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/timetracking.TaskStatusChange"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-users": {
+            "get": {
+                "description": "Get assigned users and users who worked on the task with their time, sorted by time spent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get task cost by users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Время по пользователям (users)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/timetracking.TaskUserCost"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Get tasks by filter, sort and pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get tasks by filter and pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter: field=value or field__op=value joined by \u0026\u0026, op: eq, neq, gt, gte, lt, lte, in, like, isnull",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, title, project_id, period_from, period_to, cost, estimate, status, completed_at, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TasksPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update given fields of the task, title up to 100 characters, description up to 500 characters, projectId 0 - remove the task from its project, empty hourlyRate removes the rate, empty estimate removes the estimate. The status is changed by POST /task-status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Update task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Task data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TaskData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create task, title is required (up to 100 characters), description up to 500 characters, projectId - active project of the task, hourlyRate - string like \"1500.00\", billable by default",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Create task",
+                "parameters": [
+                    {
+                        "description": "Task data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TaskData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "int32"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete task with its time entries, a started task or a task with invoiced time can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Delete task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Задача начата или интервал работы вошел в счет",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/time-entries": {
+            "get": {
+                "description": "Get time entries (intervals of work of users on tasks) by filter, sort and pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Get time entries by filter and pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter: field=value or field__op=value joined by \u0026\u0026, op: eq, neq, gt, gte, lt, lte, in, like, isnull",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, task_id, user_id, started_at, ended_at, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only entries stopped automatically (true) or by user (false)",
+                        "name": "autoStopped",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TimeEntriesPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update given fields of an ended time entry, it must not overlap other intervals of the user and must contain its pauses. Invoiced entries can't be edited, entries of done or cancelled tasks can't be edited or moved to such tasks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Update time entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Time entry ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Time entry data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.TimeEntryData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Интервал работы не завершен, вошел в счет или задача завершена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add an ended interval of work of the user on the task afterwards, it must not overlap other intervals of the user. Done and cancelled tasks don't accept time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Create time entry",
+                "parameters": [
+                    {
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    {
+                        "description": "Start of work, RFC 3339",
+                        "name": "startedAt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "End of work, RFC 3339, not in the future",
+                        "name": "endedAt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Note for the time entry",
+                        "name": "note",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "int32"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Задача завершена или отменена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an ended time entry, its time is subtracted from the task. Invoiced entries and entries of done or cancelled tasks can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Time Tracking"
+                ],
+                "summary": "Delete time entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Time entry ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Интервал работы не завершен, вошел в счет или задача завершена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get users data by filter and pagination, as JSON, CSV or XLSX. Without limit CSV and XLSX contain all users by the filter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get users data by filter and pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter: field=value or field__op=value joined by \u0026\u0026, op: eq, neq, gt, gte, lt, lte, in, like, isnull",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort: comma-separated fields, '-' for descending (id, surname, name, patronymic, address, created)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default), csv or xlsx, also chosen by the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV/XLSX columns in order: key or key:Header, comma-separated (surname, name, patronymic, address, workdayEnd, timerPolicy, created)",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.UsersPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update user data by passport series and number, hourlyRate - string like \"1500.00\", empty or null removes the rate. Only surname, name, patronymic, address, workdayEnd, timerPolicy and hourlyRate can be updated, other fields are rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update user data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passport series",
+                        "name": "pasportSeries",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create user with passport data, the id of an existing user with the same passport is returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/timetracking.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "int32"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь с этим паспортом создается параллельным запросом",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete user by passport series and number together with the time entries and assignments of the user.\nA user with a started task or invoiced time entries can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passport series",
+                        "name": "pasportSeries",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Passport number",
+                        "name": "pasportNumber",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "У пользователя идет таймер или есть оплаченное время",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "storage.PoolStats": {
+            "type": "object",
+            "properties": {
+                "acquireCount": {
+                    "description": "сколько раз соединение было получено из пула",
+                    "type": "integer"
+                },
+                "acquireDuration": {
+                    "description": "суммарное время ожидания соединений",
+                    "type": "integer"
+                },
+                "acquiredConns": {
+                    "description": "занятых соединений",
+                    "type": "integer"
+                },
+                "emptyAcquireCount": {
+                    "description": "сколько раз пришлось ждать соединение",
+                    "type": "integer"
+                },
+                "idleConns": {
+                    "description": "простаивающих соединений",
+                    "type": "integer"
+                },
+                "maxConns": {
+                    "description": "максимум соединений, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "totalConns": {
+                    "description": "всего открытых соединений",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.CurrentTimer": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "время интервала без пауз, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "entryId": {
+                    "description": "идентификатор интервала работы",
+                    "type": "integer"
+                },
+                "note": {
+                    "description": "комментарий",
+                    "type": "string"
+                },
+                "seconds": {
+                    "description": "время интервала без пауз в секундах",
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "description": "начало интервала",
+                    "type": "string"
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                },
+                "timer": {
+                    "description": "состояние отсчета времени",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TimerState"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "название задачи",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.CurrentTimers": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "идущий таймер, null - пользователь сейчас не работает",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.CurrentTimer"
+                        }
+                    ]
+                },
+                "paused": {
+                    "description": "приостановленные таймеры",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.CurrentTimer"
+                    }
+                }
+            }
+        },
+        "timetracking.EstimateReport": {
+            "type": "object",
+            "properties": {
+                "actualDuration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "actualSeconds": {
+                    "description": "затраченное время в секундах, включая идущие таймеры",
+                    "type": "integer"
+                },
+                "estimateDuration": {
+                    "description": "оценка, например \"8h 00m 00s\"",
+                    "type": "string"
+                },
+                "estimateSeconds": {
+                    "description": "оценка в секундах, 0 - не задана",
+                    "type": "integer"
+                },
+                "overdue": {
+                    "description": "количество просроченных задач",
+                    "type": "integer"
+                },
+                "overrun": {
+                    "description": "количество задач, превысивших оценку",
+                    "type": "integer"
+                },
+                "remainingDuration": {
+                    "description": "оставшаяся работа по оценке",
+                    "type": "string"
+                },
+                "remainingSeconds": {
+                    "description": "оставшаяся работа по оценке в секундах, не меньше 0",
+                    "type": "integer"
+                },
+                "tasks": {
+                    "description": "задачи в порядке сортировки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.TaskEstimate"
+                    }
+                }
+            }
+        },
+        "timetracking.Invoice": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "сумма счета",
+                    "type": "string"
+                },
+                "client": {
+                    "description": "клиент",
+                    "type": "string"
+                },
+                "created": {
+                    "description": "дата выставления",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "валюта счета",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "время по счету, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "groupBy": {
+                    "description": "строки по задачам или пользователям",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.ReportGroupBy"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "description": "строки счета",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.InvoiceLine"
+                    }
+                },
+                "number": {
+                    "description": "номер счета",
+                    "type": "string"
+                },
+                "periodFrom": {
+                    "description": "начало периода",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец периода, не включительно",
+                    "type": "string"
+                },
+                "projectId": {
+                    "description": "проект, 0 - все проекты клиента",
+                    "type": "integer"
+                },
+                "seconds": {
+                    "description": "время по счету в секундах",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.InvoiceLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "сумма строки",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "название задачи или ФИО пользователя",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invoiceId": {
+                    "description": "счет",
+                    "type": "integer"
+                },
+                "seconds": {
+                    "description": "время в секундах",
+                    "type": "integer"
+                },
+                "taskId": {
+                    "description": "задача, при строках по задачам",
+                    "type": "integer"
+                },
+                "userId": {
+                    "description": "пользователь, при строках по пользователям",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.InvoiceParams": {
+            "type": "object",
+            "properties": {
+                "client": {
+                    "description": "клиент: время всех его проектов",
+                    "type": "string"
+                },
+                "groupBy": {
+                    "description": "строки по задачам (task, по умолчанию) или пользователям (user)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.ReportGroupBy"
+                        }
+                    ]
+                },
+                "periodFrom": {
+                    "description": "начало периода, включительно",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец периода, не включительно",
+                    "type": "string"
+                },
+                "projectId": {
+                    "description": "проект, 0 - все проекты клиента",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.InvoicesPage": {
+            "type": "object",
+            "properties": {
+                "invoices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.Invoice"
+                    }
+                },
+                "limit": {
+                    "description": "размер страницы, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "ссылка на следующую страницу",
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "description": "курсор следующей страницы для параметра after",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "смещение страницы",
+                    "type": "integer"
+                },
+                "prev": {
+                    "description": "ссылка на предыдущую страницу",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "всего записей по фильтру",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.Project": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "в проекте можно вести задачи",
+                    "type": "boolean"
+                },
+                "billable": {
+                    "description": "время задач оплачивается клиентом",
+                    "type": "boolean"
+                },
+                "client": {
+                    "description": "клиент",
+                    "type": "string"
+                },
+                "code": {
+                    "description": "короткий уникальный код",
+                    "type": "string"
+                },
+                "cost": {
+                    "description": "потраченное время по всем задачам проекта",
+                    "type": "integer"
+                },
+                "created": {
+                    "description": "дата создания",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "валюта ставок, пусто - валюта по умолчанию",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "потраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка задач без своей ставки",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "название",
+                    "type": "string"
+                },
+                "periodFrom": {
+                    "description": "начало проекта",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец проекта",
+                    "type": "string"
+                },
+                "tasks": {
+                    "description": "количество задач проекта",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.ProjectData": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "в проекте можно вести задачи",
+                    "type": "boolean"
+                },
+                "billable": {
+                    "description": "время задач оплачивается клиентом, по умолчанию да",
+                    "type": "boolean"
+                },
+                "client": {
+                    "description": "клиент",
+                    "type": "string"
+                },
+                "code": {
+                    "description": "короткий уникальный код",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "валюта ставок ISO 4217, пусто - валюта по умолчанию",
+                    "type": "string"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка, например \"1500.00\", пусто - ставка не задана",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "название",
+                    "type": "string"
+                },
+                "periodFrom": {
+                    "description": "начало проекта",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец проекта",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.ProjectsPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "размер страницы, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "ссылка на следующую страницу",
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "description": "курсор следующей страницы для параметра after",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "смещение страницы",
+                    "type": "integer"
+                },
+                "prev": {
+                    "description": "ссылка на предыдущую страницу",
+                    "type": "string"
+                },
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.Project"
+                    }
+                },
+                "total": {
+                    "description": "всего записей по фильтру",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.Report": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "стоимость оплачиваемого времени по валютам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "billableSeconds": {
+                    "description": "оплачиваемое время в секундах",
+                    "type": "integer"
+                },
+                "buckets": {
+                    "description": "все периоды по порядку, включая периоды без времени",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.ReportBucket"
+                    }
+                },
+                "duration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "начало отчета",
+                    "type": "string"
+                },
+                "groupBy": {
+                    "description": "группировка",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.ReportGroupBy"
+                        }
+                    ]
+                },
+                "period": {
+                    "description": "длина периода",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.ReportPeriod"
+                        }
+                    ]
+                },
+                "seconds": {
+                    "description": "затраченное время в секундах",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "конец отчета, не включительно",
+                    "type": "string"
+                },
+                "totals": {
+                    "description": "итоги по задачам, пользователям или проектам за весь отчет, по убыванию",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.ReportRow"
+                    }
+                }
+            }
+        },
+        "timetracking.ReportBucket": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "стоимость оплачиваемого времени по валютам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "billableSeconds": {
+                    "description": "оплачиваемое время в секундах",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "end": {
+                    "description": "конец периода, не включительно",
+                    "type": "string"
+                },
+                "rows": {
+                    "description": "время по задачам, пользователям или проектам, по убыванию",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.ReportRow"
+                    }
+                },
+                "seconds": {
+                    "description": "затраченное время в секундах",
+                    "type": "integer"
+                },
+                "start": {
+                    "description": "начало периода",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.ReportGroupBy": {
+            "type": "string",
+            "enum": [
+                "task",
+                "user",
+                "project"
+            ],
+            "x-enum-comments": {
+                "ReportByProject": "по проектам, задачи без проекта - в строке с id 0",
+                "ReportByTask": "по задачам",
+                "ReportByUser": "по пользователям"
+            },
+            "x-enum-varnames": [
+                "ReportByTask",
+                "ReportByUser",
+                "ReportByProject"
+            ]
+        },
+        "timetracking.ReportPeriod": {
+            "type": "string",
+            "enum": [
+                "day",
+                "week",
+                "month"
+            ],
+            "x-enum-comments": {
+                "ReportDay": "сутки",
+                "ReportMonth": "календарный месяц",
+                "ReportWeek": "неделя с понедельника"
+            },
+            "x-enum-varnames": [
+                "ReportDay",
+                "ReportWeek",
+                "ReportMonth"
+            ]
+        },
+        "timetracking.ReportRow": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "стоимость оплачиваемого времени по валютам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "billableSeconds": {
+                    "description": "оплачиваемое время в секундах",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "идентификатор задачи, пользователя или проекта",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "название задачи или проекта, ФИО пользователя",
+                    "type": "string"
+                },
+                "seconds": {
+                    "description": "затраченное время в секундах",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.Task": {
+            "type": "object",
+            "properties": {
+                "billable": {
+                    "description": "время оплачивается клиентом",
+                    "type": "boolean"
+                },
+                "completedAt": {
+                    "description": "завершение задачи, nil - не завершена",
+                    "type": "string"
+                },
+                "cost": {
+                    "description": "потраченное время всех пользователей",
+                    "type": "integer"
+                },
+                "created": {
+                    "description": "дата создания",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "описание",
+                    "type": "string"
+                },
+                "estimate": {
+                    "description": "оценка трудоемкости, 0 - не задана",
+                    "type": "integer"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка, null - ставка проекта или пользователя",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "periodFrom": {
+                    "description": "начало периода",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец периода",
+                    "type": "string"
+                },
+                "projectId": {
+                    "description": "проект, 0 - задача без проекта",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "статус задачи: todo, in_progress, paused, done, cancelled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TaskStatus"
+                        }
+                    ]
+                },
+                "timer": {
+                    "description": "состояние отсчета времени: running - время идет хотя бы у одного пользователя",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TimerState"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "название",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.TaskCost": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "running": {
+                    "description": "время по задаче еще идет",
+                    "type": "boolean"
+                },
+                "seconds": {
+                    "description": "затраченное время в секундах",
+                    "type": "integer"
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                },
+                "timer": {
+                    "description": "состояние отсчета времени",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TimerState"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "название задачи",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.TaskData": {
+            "type": "object",
+            "properties": {
+                "billable": {
+                    "description": "время оплачивается клиентом, по умолчанию да",
+                    "type": "boolean"
+                },
+                "description": {
+                    "description": "описание",
+                    "type": "string"
+                },
+                "estimate": {
+                    "description": "оценка трудоемкости, например \"8h\" или \"1h30m\", пусто - оценка не задана",
+                    "type": "string"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка, например \"1500.00\", пусто - ставка проекта или пользователя",
+                    "type": "string"
+                },
+                "periodFrom": {
+                    "description": "начало периода",
+                    "type": "string"
+                },
+                "periodTo": {
+                    "description": "конец периода",
+                    "type": "string"
+                },
+                "projectId": {
+                    "description": "проект, 0 - задача без проекта",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "название",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.TaskEstimate": {
+            "type": "object",
+            "properties": {
+                "actualDuration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "actualSeconds": {
+                    "description": "затраченное время в секундах, включая идущие таймеры",
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "description": "завершение задачи, nil - не завершена",
+                    "type": "string"
+                },
+                "estimateDuration": {
+                    "description": "оценка, например \"8h 00m 00s\"",
+                    "type": "string"
+                },
+                "estimateSeconds": {
+                    "description": "оценка в секундах, 0 - не задана",
+                    "type": "integer"
+                },
+                "overdue": {
+                    "description": "конец периода прошел, а задача не завершена и не отменена",
+                    "type": "boolean"
+                },
+                "overrun": {
+                    "description": "затраченное время превысило оценку",
+                    "type": "boolean"
+                },
+                "periodTo": {
+                    "description": "конец периода задачи",
+                    "type": "string"
+                },
+                "projectId": {
+                    "description": "проект, 0 - задача без проекта",
+                    "type": "integer"
+                },
+                "remainingDuration": {
+                    "description": "оставшаяся работа по оценке",
+                    "type": "string"
+                },
+                "remainingSeconds": {
+                    "description": "оставшаяся работа по оценке в секундах, не меньше 0",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "статус задачи",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TaskStatus"
+                        }
+                    ]
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                },
+                "timer": {
+                    "description": "состояние отсчета времени",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TimerState"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "название задачи",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.TaskStatus": {
+            "type": "string",
+            "enum": [
+                "todo",
+                "in_progress",
+                "paused",
+                "done",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "TaskCancelled": "задача отменена",
+                "TaskDone": "задача завершена",
+                "TaskInProgress": "задача в работе",
+                "TaskPaused": "работа отложена",
+                "TaskTodo": "работа не начата"
+            },
+            "x-enum-varnames": [
+                "TaskTodo",
+                "TaskInProgress",
+                "TaskPaused",
+                "TaskDone",
+                "TaskCancelled"
+            ]
+        },
+        "timetracking.TaskStatusChange": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "время смены статуса",
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "description": "прежний статус, пусто - задача создана",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TaskStatus"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "description": "комментарий",
+                    "type": "string"
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                },
+                "toStatus": {
+                    "description": "новый статус",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TaskStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "timetracking.TaskStatusData": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "description": "комментарий, например причина отмены",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "новый статус",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TaskStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "timetracking.TaskUserCost": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "description": "пользователь назначен на задачу",
+                    "type": "boolean"
+                },
+                "duration": {
+                    "description": "затраченное время, например \"1h 05m 09s\"",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "имя",
+                    "type": "string"
+                },
+                "patronymic": {
+                    "description": "отчество",
+                    "type": "string"
+                },
+                "running": {
+                    "description": "время пользователя по задаче идет",
+                    "type": "boolean"
+                },
+                "seconds": {
+                    "description": "затраченное время в секундах",
+                    "type": "integer"
+                },
+                "surname": {
+                    "description": "фамилия",
+                    "type": "string"
+                },
+                "timer": {
+                    "description": "состояние отсчета времени пользователя",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/timetracking.TimerState"
+                        }
+                    ]
+                },
+                "userId": {
+                    "description": "идентификатор пользователя",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.TasksPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "размер страницы, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "ссылка на следующую страницу",
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "description": "курсор следующей страницы для параметра after",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "смещение страницы",
+                    "type": "integer"
+                },
+                "prev": {
+                    "description": "ссылка на предыдущую страницу",
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.Task"
+                    }
+                },
+                "total": {
+                    "description": "всего записей по фильтру",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.TimeEntriesPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "размер страницы, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "ссылка на следующую страницу",
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "description": "курсор следующей страницы для параметра after",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "смещение страницы",
+                    "type": "integer"
+                },
+                "prev": {
+                    "description": "ссылка на предыдущую страницу",
+                    "type": "string"
+                },
+                "timeEntries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.TimeEntry"
+                    }
+                },
+                "total": {
+                    "description": "всего записей по фильтру",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.TimeEntry": {
+            "type": "object",
+            "properties": {
+                "autoStopped": {
+                    "description": "остановлен автоматически, требует проверки",
+                    "type": "boolean"
+                },
+                "created": {
+                    "description": "дата создания",
+                    "type": "string"
+                },
+                "endedAt": {
+                    "description": "конец работы, nil - работа идет",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invoiceId": {
+                    "description": "счет, в который вошел интервал, 0 - не оплачен",
+                    "type": "integer"
+                },
+                "note": {
+                    "description": "комментарий",
+                    "type": "string"
+                },
+                "pauses": {
+                    "description": "паузы по порядку начала",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.TimeEntryPause"
+                    }
+                },
+                "startedAt": {
+                    "description": "начало работы",
+                    "type": "string"
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                },
+                "userId": {
+                    "description": "идентификатор пользователя",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.TimeEntryData": {
+            "type": "object",
+            "properties": {
+                "endedAt": {
+                    "description": "конец работы",
+                    "type": "string"
+                },
+                "note": {
+                    "description": "комментарий",
+                    "type": "string"
+                },
+                "startedAt": {
+                    "description": "начало работы",
+                    "type": "string"
+                },
+                "taskId": {
+                    "description": "идентификатор задачи",
+                    "type": "integer"
+                }
+            }
+        },
+        "timetracking.TimeEntryPause": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "дата создания",
+                    "type": "string"
+                },
+                "endedAt": {
+                    "description": "конец паузы, nil - пауза идет",
+                    "type": "string"
+                },
+                "entryId": {
+                    "description": "идентификатор интервала работы",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "description": "начало паузы",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.TimerState": {
+            "type": "string",
+            "enum": [
+                "running",
+                "paused",
+                "stopped"
+            ],
+            "x-enum-comments": {
+                "TimerPaused": "отсчет приостановлен",
+                "TimerRunning": "время идет",
+                "TimerStopped": "отсчет не идет"
+            },
+            "x-enum-varnames": [
+                "TimerRunning",
+                "TimerPaused",
+                "TimerStopped"
+            ]
+        },
+        "timetracking.User": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "адрес",
+                    "type": "string"
+                },
+                "created": {
+                    "description": "дата создания",
+                    "type": "string"
+                },
+                "hourlyRate": {
+                    "description": "почасовая ставка, null - не задана",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "имя",
+                    "type": "string"
+                },
+                "patronymic": {
+                    "description": "отчество",
+                    "type": "string"
+                },
+                "surname": {
+                    "description": "фамилия",
+                    "type": "string"
+                },
+                "timerPolicy": {
+                    "description": "политика одного таймера, пусто - общая",
+                    "type": "string"
+                },
+                "workdayEnd": {
+                    "description": "конец рабочего дня \"ЧЧ:ММ\"",
+                    "type": "string"
+                }
+            }
+        },
+        "timetracking.UsersPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "размер страницы, 0 - без ограничения",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "ссылка на следующую страницу",
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "description": "курсор следующей страницы для параметра after",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "смещение страницы",
+                    "type": "integer"
+                },
+                "prev": {
+                    "description": "ссылка на предыдущую страницу",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "всего записей по фильтру",
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/timetracking.User"
+                    }
                 }
             }
         }
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/joho/godotenv"
 
+	"timetracking/memory"
 	"timetracking/posgresql"
+	"timetracking/sqlite"
+	"timetracking/storage"
 	"timetracking/timetracking"
 )
 
@@ -20,17 +26,44 @@ func main() {
 
 	Logger.Debug("Starting timetracking service")
 
-	Logger.Debug("Loading posgresql config")
-	pgconfig, err := loadPGConfig()
+	// .env нужен только для posgresql, для остальных хранилищ он необязателен
+	_ = godotenv.Load()
+
+	defaultStorage, ok := os.LookupEnv("storage")
+	if !ok || defaultStorage == "" {
+		defaultStorage = "postgres"
+	}
+
+	storageKind := flag.String("storage", defaultStorage, "storage backend: postgres, sqlite or memory")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "deadline for handling one http request, 0 - no deadline")
+	maxTimerDuration := flag.Duration("max-timer-duration", 12*time.Hour, "stop timers running longer than this automatically, 0 - no limit")
+	sweepInterval := flag.Duration("sweep-interval", time.Minute, "how often forgotten timers are checked, 0 - never")
+	timerPolicy := flag.String("timer-policy", string(timetracking.TimerPolicyReject), "when a user starts a task while another timer runs: reject or switch (stop the running timer)")
+	currencyCode := flag.String("currency", timetracking.DefaultCurrency, "currency of hourly rates of tasks without a project and projects without their own currency")
+	workdayTZ := flag.String("workday-tz", "Local", "time zone of users' workday end, IANA name like Europe/Moscow, UTC or Local (server time zone)")
+	flag.Parse()
+
+	policy, err := timetracking.ParseTimerPolicy(*timerPolicy)
 	if err != nil {
-		Logger.Error("load posgresql config failed", slog.String("error", err.Error()))
+		Logger.Error("invalid timer policy", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	Logger.Debug("New posgresql storage")
-	db, err := posgresql.NewPosgresqlStorage(pgconfig)
+	currency, err := timetracking.ParseCurrency(*currencyCode)
 	if err != nil {
-		Logger.Error("new posgresql storage failed", slog.String("error", err.Error()))
+		Logger.Error("invalid currency", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	workdayLocation, err := time.LoadLocation(*workdayTZ)
+	if err != nil {
+		Logger.Error("invalid workday time zone", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	db, err := newStorage(*storageKind)
+	if err != nil {
+		Logger.Error("new storage failed", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	defer db.Close()
@@ -40,9 +73,22 @@ func main() {
 	fiberApp := fiber.New()
 	groupTTS := fiberApp.Group("/")
 
-	app := timetracking.NewTimeTrackingService(db)
+	app := timetracking.NewTimeTrackingService(db,
+		timetracking.WithRequestTimeout(*requestTimeout),
+		timetracking.WithMaxTimerDuration(*maxTimerDuration),
+		timetracking.WithTimerPolicy(policy),
+		timetracking.WithCurrency(currency),
+		timetracking.WithWorkdayLocation(workdayLocation),
+	)
 	app.SetupHandlers(groupTTS)
 
+	// Фоновая остановка забытых таймеров
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *sweepInterval > 0 {
+		go app.RunSweeper(ctx, *sweepInterval)
+	}
+
 	Logger.Debug("Starting server")
 	if err := fiberApp.Listen(":3000"); err != nil {
 		Logger.Error("fiber listen failed", slog.String("error", err.Error()))
@@ -52,6 +98,47 @@ func main() {
 	Logger.Debug("Server stoped")
 }
 
+type closableStorage interface {
+	storage.Storage
+	Close() error
+}
+
+// newStorage - создание хранилища по названию
+func newStorage(kind string) (closableStorage, error) {
+	switch kind {
+	case "postgres":
+		Logger.Debug("Loading posgresql config")
+		pgconfig, err := loadPGConfig()
+		if err != nil {
+			return nil, fmt.Errorf("load posgresql config failed: %w", err)
+		}
+
+		Logger.Debug("New posgresql storage")
+		db, err := posgresql.NewPosgresqlStorage(pgconfig)
+		if err != nil {
+			return nil, fmt.Errorf("new posgresql storage failed: %w", err)
+		}
+		return db, nil
+
+	case "sqlite":
+		Logger.Debug("Loading sqlite config")
+		sqliteConfig := loadSqliteConfig()
+
+		Logger.Debug("New sqlite storage")
+		db, err := sqlite.NewSqliteStorage(sqliteConfig)
+		if err != nil {
+			return nil, fmt.Errorf("new sqlite storage failed: %w", err)
+		}
+		return db, nil
+
+	case "memory":
+		Logger.Debug("New memory storage")
+		return memory.NewMemoryStorage(), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage %q", kind)
+}
+
 // malual load config from .env file
 func loadPGConfig() (*posgresql.PsqlConfig, error) {
 	err := godotenv.Load()
@@ -78,11 +165,71 @@ func loadPGConfig() (*posgresql.PsqlConfig, error) {
 
 	Logger.Debug("loaded .env file typed", "host", host, "port", portInt, "username", username, "password", password, "database", database)
 
-	return &posgresql.PsqlConfig{
+	config := &posgresql.PsqlConfig{
 		Host:     host,
 		Port:     portInt,
 		Username: username,
 		Password: password,
 		Database: database,
-	}, nil
+	}
+
+	// необязательные настройки пула соединений
+	if err := loadPoolConfig(config); err != nil {
+		return nil, fmt.Errorf("load .env file failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// load optional connection pool settings, empty values keep pgxpool defaults
+func loadPoolConfig(config *posgresql.PsqlConfig) error {
+	if v, ok := os.LookupEnv("pool_min_conns"); ok && v != "" {
+		minConns, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return fmt.Errorf("pool_min_conns: %w", err)
+		}
+		config.MinConns = int32(minConns)
+	}
+
+	if v, ok := os.LookupEnv("pool_max_conns"); ok && v != "" {
+		maxConns, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return fmt.Errorf("pool_max_conns: %w", err)
+		}
+		config.MaxConns = int32(maxConns)
+	}
+
+	if v, ok := os.LookupEnv("pool_max_conn_idle_time"); ok && v != "" {
+		idleTime, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("pool_max_conn_idle_time: %w", err)
+		}
+		config.MaxConnIdleTime = idleTime
+	}
+
+	if v, ok := os.LookupEnv("pool_health_check_period"); ok && v != "" {
+		period, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("pool_health_check_period: %w", err)
+		}
+		config.HealthCheckPeriod = period
+	}
+
+	Logger.Debug("loaded pool config", "minConns", config.MinConns, "maxConns", config.MaxConns, "maxConnIdleTime", config.MaxConnIdleTime, "healthCheckPeriod", config.HealthCheckPeriod)
+
+	return nil
+}
+
+// load sqlite config from environment or .env file
+func loadSqliteConfig() *sqlite.SqliteConfig {
+	path, ok := os.LookupEnv("sqlite_path")
+	if !ok || path == "" {
+		path = "timetracking.db"
+	}
+
+	Logger.Debug("loaded sqlite config", "path", path)
+
+	return &sqlite.SqliteConfig{
+		Path: path,
+	}
 }
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// matchFilter - проверка записи на соответствие фильтру
+func matchFilter(fields map[string]any, filter Filter) (bool, error) {
+	switch f := filter.(type) {
+	case nil:
+		return true, nil
+
+	case Match:
+		for k, v := range f {
+			if !equalValues(fields[k], v) {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case Group:
+		for _, item := range f.Filters {
+			ok, err := matchFilter(fields, item)
+			if err != nil {
+				return false, err
+			}
+			if f.Or && ok {
+				return true, nil
+			}
+			if !f.Or && !ok {
+				return false, nil
+			}
+		}
+		// пустая группа OR не выбирает ничего, пустая AND - все
+		return !f.Or, nil
+
+	case Condition:
+		return matchCondition(fields, f)
+	}
+
+	return false, fmt.Errorf("unknown filter %T", filter)
+}
+
+func matchCondition(fields map[string]any, c Condition) (bool, error) {
+	if c.Field == "" {
+		return false, fmt.Errorf("empty field in condition %s", c.Operator)
+	}
+
+	field := normalize(fields[c.Field])
+
+	switch c.Operator {
+	case OpEq:
+		return equalValues(field, c.Value), nil
+
+	case OpNeq:
+		// сравнение с NULL в SQL не выполняется никогда
+		if field == nil || c.Value == nil {
+			return false, nil
+		}
+		return !equalValues(field, c.Value), nil
+
+	case OpGt, OpGte, OpLt, OpLte:
+		cmp, ok := compareValues(field, c.Value)
+		if !ok {
+			return false, nil
+		}
+		switch c.Operator {
+		case OpGt:
+			return cmp > 0, nil
+		case OpGte:
+			return cmp >= 0, nil
+		case OpLt:
+			return cmp < 0, nil
+		}
+		return cmp <= 0, nil
+
+	case OpIn:
+		values, ok := c.Value.([]any)
+		if !ok {
+			return false, fmt.Errorf("field %s: in expects []any, got %T", c.Field, c.Value)
+		}
+		for _, v := range values {
+			if equalValues(field, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case OpLike:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("field %s: like expects string, got %T", c.Field, c.Value)
+		}
+		s, ok := field.(string)
+		if !ok {
+			return false, nil
+		}
+		return likeRegexp(pattern).MatchString(s), nil
+
+	case OpIsNull:
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			return false, fmt.Errorf("field %s: isnull expects bool, got %T", c.Field, c.Value)
+		}
+		return (field == nil) == isNull, nil
+	}
+
+	return false, fmt.Errorf("field %s: unknown operator %q", c.Field, c.Operator)
+}
+
+// likeRegexp - шаблон LIKE в регулярное выражение: % - любая строка, _ - один символ
+func likeRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// equalValues - сравнение значений как в базе данных:
+// строковые значения фильтра приводятся к типу поля, nil - IS NULL
+func equalValues(field, value any) bool {
+	field, value = normalize(field), normalize(value)
+	if field == nil || value == nil {
+		return field == nil && value == nil
+	}
+
+	if cmp, ok := compareValues(field, value); ok {
+		return cmp == 0
+	}
+
+	fv, vv := reflect.ValueOf(field), reflect.ValueOf(value)
+	if fv.Type() == vv.Type() && fv.Comparable() {
+		return field == value
+	}
+
+	return fmt.Sprint(field) == fmt.Sprint(value)
+}
+
+// compareValues - сравнение упорядочиваемых значений: чисел, времени и строк.
+// Строка сравнивается с числом или временем после разбора. ok = false, если значения несравнимы
+func compareValues(field, value any) (cmp int, ok bool) {
+	field, value = normalize(field), normalize(value)
+	if field == nil || value == nil {
+		return 0, false
+	}
+
+	switch f := field.(type) {
+	case time.Time:
+		v, ok := toTime(value)
+		if !ok {
+			return 0, false
+		}
+		return f.Compare(v), true
+
+	case string:
+		if v, ok := value.(string); ok {
+			return strings.Compare(f, v), true
+		}
+		if _, ok := value.(time.Time); ok {
+			cmp, ok := compareValues(value, field)
+			return -cmp, ok
+		}
+	}
+
+	fn, okField := toFloat(field)
+	vn, okValue := toFloat(value)
+	if !okField || !okValue {
+		return 0, false
+	}
+
+	switch {
+	case fn < vn:
+		return -1, true
+	case fn > vn:
+		return 1, true
+	}
+	return 0, true
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(rv.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// timeLayouts - форматы времени, которые принимает база данных в строковых литералах
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+func toTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareRecords - сравнение записей по сортировке, NULL и несравнимые значения в конце
+func compareRecords(a, b map[string]any, sort []Sort) int {
+	for _, s := range sort {
+		va, vb := normalize(a[s.Field]), normalize(b[s.Field])
+
+		switch {
+		case va == nil && vb == nil:
+			continue
+		case va == nil:
+			return 1
+		case vb == nil:
+			return -1
+		}
+
+		cmp, ok := compareValues(va, vb)
+		if !ok {
+			cmp = strings.Compare(fmt.Sprint(va), fmt.Sprint(vb))
+		}
+		if s.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
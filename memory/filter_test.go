@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	. "timetracking/storage"
+)
+
+func TestMatchFilter(t *testing.T) {
+	started := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	fields := map[string]any{
+		"id":         int32(3),
+		"title":      "Задача 3",
+		"cost":       90 * time.Minute,
+		"started_at": started,
+		"ended_at":   nil,
+	}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    bool
+		wantErr bool
+	}{
+		{name: "nil filter", filter: nil, want: true},
+		{name: "match", filter: Match{"id": 3, "title": "Задача 3"}, want: true},
+		{name: "match mismatch", filter: Match{"id": 4}, want: false},
+		{name: "match null", filter: Match{"ended_at": nil}, want: true},
+		{name: "eq string value is converted", filter: Eq("id", "3"), want: true},
+		{name: "eq duration", filter: Eq("cost", int64(90*time.Minute)), want: true},
+		{name: "neq", filter: Neq("id", 4), want: true},
+		{name: "neq null never matches", filter: Neq("ended_at", 4), want: false},
+		{name: "gt", filter: Gt("id", 2), want: true},
+		{name: "gte equal", filter: Gte("id", "3"), want: true},
+		{name: "lt", filter: Lt("id", 3), want: false},
+		{name: "lte time string", filter: Lte("started_at", "2024-07-01T10:00:00Z"), want: true},
+		{name: "gt time", filter: Gt("started_at", started), want: false},
+		{name: "compare with null", filter: Gt("ended_at", started), want: false},
+		{name: "in", filter: In("id", 1, 3), want: true},
+		{name: "in strings", filter: In("id", "1", "2"), want: false},
+		{name: "like", filter: Like("title", "Задача%"), want: true},
+		{name: "like one character", filter: Like("title", "Задача _"), want: true},
+		{name: "like escapes regexp", filter: Like("title", "Задача."), want: false},
+		{name: "isnull", filter: IsNull("ended_at"), want: true},
+		{name: "is not null", filter: IsNotNull("started_at"), want: true},
+		{name: "and", filter: And(Eq("id", 3), IsNull("ended_at")), want: true},
+		{name: "and mismatch", filter: And(Eq("id", 3), IsNotNull("ended_at")), want: false},
+		{name: "or", filter: Or(Eq("id", 4), Eq("id", 3)), want: true},
+		{name: "empty and", filter: And(), want: true},
+		{name: "empty or", filter: Or(), want: false},
+		{name: "in without list", filter: Condition{Field: "id", Operator: OpIn, Value: 3}, wantErr: true},
+		{name: "isnull without bool", filter: Condition{Field: "id", Operator: OpIsNull, Value: "true"}, wantErr: true},
+		{name: "unknown operator", filter: Condition{Field: "id", Operator: "between", Value: 3}, wantErr: true},
+		{name: "empty field", filter: Eq("", 3), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilter(fields, tt.filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("matchFilter(%#v) error = nil, want error", tt.filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchFilter(%#v) error = %v", tt.filter, err)
+			}
+			if got != tt.want {
+				t.Errorf("matchFilter(%#v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
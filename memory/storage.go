@@ -0,0 +1,399 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "timetracking/storage"
+)
+
+var Logger = slog.Default()
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// Коллекция - записи по идентификатору и счетчик идентификаторов
+type collection struct {
+	lastId  int32
+	records map[int32]map[string]any
+}
+
+// uniqueKeys - уникальные индексы коллекций, те же, что в миграциях баз данных
+var uniqueKeys = map[string][][]string{
+	UserCollection:           {{"pasport_series", "pasport_number"}},
+	ProjectCollection:        {{"code"}},
+	InvoiceCollection:        {{"number"}},
+	TaskAssignmentCollection: {{"task_id", "user_id"}},
+}
+
+// duplicateKey - уникальный ключ, по которому запись id совпадает с другой записью коллекции,
+// nil - совпадений нет. Ключ с NULL не сравнивается, как в уникальном индексе базы данных
+func duplicateKey(name string, records map[int32]map[string]any, id int32) []string {
+	fields := records[id]
+
+	for _, key := range uniqueKeys[name] {
+		if slices.ContainsFunc(key, func(k string) bool { return fields[k] == nil }) {
+			continue
+		}
+
+		for otherId, other := range records {
+			if otherId == id {
+				continue
+			}
+			if !slices.ContainsFunc(key, func(k string) bool { return !equalValues(other[k], fields[k]) }) {
+				return key
+			}
+		}
+	}
+
+	return nil
+}
+
+// MemoryStorage - хранилище в памяти, безопасно для конкурентного использования
+type MemoryStorage struct {
+	mu          rwLocker // внутри транзакции - noLock, блокировка уже захвачена
+	inTx        bool
+	collections map[string]*collection
+}
+
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// noLock - заглушка блокировки для операций внутри транзакции
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
+func NewMemoryStorage() *MemoryStorage {
+	Logger.Info("memory: created")
+
+	return &MemoryStorage{
+		mu:          &sync.RWMutex{},
+		collections: map[string]*collection{},
+	}
+}
+
+func (s *MemoryStorage) Close() error {
+	Logger.Info("memory: closing")
+	return nil
+}
+
+// getCollection - получить коллекцию, создает ее при отсутствии
+func (s *MemoryStorage) getCollection(name string) *collection {
+	c, ok := s.collections[name]
+	if !ok {
+		c = &collection{records: map[int32]map[string]any{}}
+		s.collections[name] = c
+	}
+	return c
+}
+
+// getCollectionOrNil - получить коллекцию без создания
+func (s *MemoryStorage) getCollectionOrNil(name string) *collection {
+	return s.collections[name]
+}
+
+type recordReader struct {
+	records []*Record
+	current int
+}
+
+func (r *recordReader) Next() bool {
+	if r.current >= len(r.records) {
+		return false
+	}
+	r.current++
+	return true
+}
+
+// Err - записи уже выбраны, ошибок чтения не бывает
+func (r *recordReader) Err() error {
+	return nil
+}
+
+func (r *recordReader) Close() {}
+
+func (r *recordReader) Read() (*Record, error) {
+	if r.current == 0 || r.current > len(r.records) {
+		return nil, sql.ErrNoRows
+	}
+	return r.records[r.current-1], nil
+}
+
+func (s *MemoryStorage) Select(ctx context.Context, collection string, filter Filter, sort []Sort, limit, offset int) (RecordReader, error) {
+	Logger.Debug("memory: select", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("sort", sort), slog.Int("limit", limit), slog.Int("offset", offset))
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("memory: select failed: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.getCollectionOrNil(collection)
+	if c == nil {
+		return &recordReader{}, nil
+	}
+
+	ids := make([]int32, 0, len(c.records))
+	for id, fields := range c.records {
+		ok, err := matchFilter(fields, filter)
+		if err != nil {
+			Logger.Info("memory: select failed", slog.String("error", err.Error()))
+			return nil, fmt.Errorf("memory: select failed: %w", err)
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	// Без сортировки порядок выдачи - по идентификатору, как порядок вставки в базе данных
+	slices.SortStableFunc(ids, func(a, b int32) int {
+		if result := compareRecords(c.records[a], c.records[b], sort); result != 0 {
+			return result
+		}
+		return cmp.Compare(a, b)
+	})
+
+	if offset > 0 {
+		if offset > len(ids) {
+			offset = len(ids)
+		}
+		ids = ids[offset:]
+	}
+	if limit > 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+
+	records := make([]*Record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, &Record{
+			Collection: collection,
+			Id:         id,
+			Fields:     copyFields(c.records[id]),
+		})
+	}
+
+	Logger.Debug("memory: select success", slog.Int("count", len(records)))
+
+	return &recordReader{records: records}, nil
+}
+
+func (s *MemoryStorage) Count(ctx context.Context, collection string, filter Filter) (int64, error) {
+	Logger.Debug("memory: count", slog.String("collection", collection), slog.Any("filter", filter))
+
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("memory: count failed: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.getCollectionOrNil(collection)
+	if c == nil {
+		return 0, nil
+	}
+
+	var count int64
+	for _, fields := range c.records {
+		ok, err := matchFilter(fields, filter)
+		if err != nil {
+			Logger.Info("memory: count failed", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("memory: count failed: %w", err)
+		}
+		if ok {
+			count++
+		}
+	}
+
+	Logger.Debug("memory: count success", slog.Int64("count", count))
+	return count, nil
+}
+
+func (s *MemoryStorage) Update(ctx context.Context, collection string, filter Filter, update map[string]any) error {
+	Logger.Debug("memory: update", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("update", update))
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("memory: update failed: %w", err)
+	}
+
+	if _, ok := update["id"]; ok {
+		Logger.Info("memory: update failed", slog.String("error", "id can not be updated"))
+		return fmt.Errorf("memory: update failed: id can not be updated")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.getCollectionOrNil(collection)
+	if c == nil {
+		return nil
+	}
+
+	// Записи меняются только вместе, если ни одна не нарушает уникальный индекс
+	updated := map[int32]map[string]any{}
+	for id, fields := range c.records {
+		ok, err := matchFilter(fields, filter)
+		if err != nil {
+			Logger.Info("memory: update failed", slog.String("error", err.Error()))
+			return fmt.Errorf("memory: update failed: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		fields = copyFields(fields)
+		for k, v := range update {
+			fields[k] = normalize(v)
+		}
+		updated[id] = fields
+	}
+
+	if len(uniqueKeys[collection]) > 0 {
+		after := maps.Clone(c.records)
+		maps.Copy(after, updated)
+		for id := range updated {
+			if key := duplicateKey(collection, after, id); key != nil {
+				Logger.Info("memory: update failed", slog.String("error", "duplicate "+strings.Join(key, ", ")))
+				return fmt.Errorf("memory: update failed: %w: %s", ErrDuplicate, strings.Join(key, ", "))
+			}
+		}
+	}
+	maps.Copy(c.records, updated)
+
+	Logger.Debug("memory: update success")
+
+	return nil
+}
+
+func (s *MemoryStorage) Insert(ctx context.Context, collection string, data map[string]any) (int32, error) {
+	Logger.Debug("memory: insert", slog.String("collection", collection), slog.Any("data", data))
+
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("memory: insert failed: %w", err)
+	}
+
+	if _, ok := data["id"]; ok {
+		Logger.Info("memory: insert failed", slog.String("error", "id is generated by storage"))
+		return 0, fmt.Errorf("memory: insert failed: id is generated by storage")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.getCollection(collection)
+	id := c.lastId + 1
+
+	fields := map[string]any{
+		"id":      id,
+		"created": time.Now().UTC(),
+	}
+	for k, v := range data {
+		fields[k] = normalize(v)
+	}
+	c.records[id] = fields
+
+	if key := duplicateKey(collection, c.records, id); key != nil {
+		delete(c.records, id)
+		Logger.Info("memory: insert failed", slog.String("error", "duplicate "+strings.Join(key, ", ")))
+		return 0, fmt.Errorf("memory: insert failed: %w: %s", ErrDuplicate, strings.Join(key, ", "))
+	}
+	c.lastId = id
+
+	Logger.Debug("memory: insert success")
+	return id, nil
+}
+
+func (s *MemoryStorage) Delete(ctx context.Context, collection string, id int32) error {
+	Logger.Debug("memory: delete", slog.String("collection", collection), slog.Int("id", int(id)))
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("memory: delete failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := s.getCollectionOrNil(collection); c != nil {
+		delete(c.records, id)
+	}
+
+	Logger.Debug("memory: delete success")
+
+	return nil
+}
+
+// WithTx - транзакция держит блокировку на запись до завершения,
+// при ошибке восстанавливается снимок данных
+func (s *MemoryStorage) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	Logger.Debug("memory: begin transaction")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Ожидание блокировки не прерывается, поэтому контекст проверяется после нее
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("memory: begin transaction failed: %w", err)
+	}
+
+	snapshot := copyCollections(s.collections)
+
+	if err := fn(&MemoryStorage{mu: noLock{}, inTx: true, collections: s.collections}); err != nil {
+		Logger.Debug("memory: rollback transaction", slog.String("error", err.Error()))
+		s.collections = snapshot
+		return err
+	}
+
+	Logger.Debug("memory: commit transaction success")
+
+	return nil
+}
+
+// normalize - приведение значений к типам, которые вернула бы база данных
+func normalize(v any) any {
+	switch v := v.(type) {
+	case time.Duration:
+		return int64(v)
+	case time.Time:
+		return v.UTC()
+	case int:
+		return int64(v)
+	}
+	return v
+}
+
+func copyFields(fields map[string]any) map[string]any {
+	copied := make(map[string]any, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return copied
+}
+
+func copyCollections(collections map[string]*collection) map[string]*collection {
+	copied := make(map[string]*collection, len(collections))
+	for name, c := range collections {
+		records := make(map[int32]map[string]any, len(c.records))
+		for id, fields := range c.records {
+			records[id] = copyFields(fields)
+		}
+		copied[name] = &collection{lastId: c.lastId, records: records}
+	}
+	return copied
+}
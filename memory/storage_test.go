@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	. "timetracking/storage"
+)
+
+func TestInsertUnique(t *testing.T) {
+	tests := []struct {
+		name       string
+		collection string
+		existing   []map[string]any
+		data       map[string]any
+		duplicate  bool
+	}{
+		{
+			name:       "same project code",
+			collection: ProjectCollection,
+			existing:   []map[string]any{{"name": "A", "code": "A1"}},
+			data:       map[string]any{"name": "B", "code": "A1"},
+			duplicate:  true,
+		},
+		{
+			name:       "other project code",
+			collection: ProjectCollection,
+			existing:   []map[string]any{{"name": "A", "code": "A1"}},
+			data:       map[string]any{"name": "B", "code": "B1"},
+		},
+		{
+			name:       "same passport",
+			collection: UserCollection,
+			existing:   []map[string]any{{"pasport_series": "1234", "pasport_number": "567890"}},
+			data:       map[string]any{"pasport_series": "1234", "pasport_number": "567890"},
+			duplicate:  true,
+		},
+		{
+			name:       "same passport number in another series",
+			collection: UserCollection,
+			existing:   []map[string]any{{"pasport_series": "1234", "pasport_number": "567890"}},
+			data:       map[string]any{"pasport_series": "4321", "pasport_number": "567890"},
+		},
+		{
+			name:       "same invoice number",
+			collection: InvoiceCollection,
+			existing:   []map[string]any{{"number": "INV-2024-0001"}},
+			data:       map[string]any{"number": "INV-2024-0001"},
+			duplicate:  true,
+		},
+		{
+			name:       "same assignment",
+			collection: TaskAssignmentCollection,
+			existing:   []map[string]any{{"task_id": int32(1), "user_id": int32(2)}},
+			data:       map[string]any{"task_id": 1, "user_id": 2},
+			duplicate:  true,
+		},
+		{
+			name:       "assignment of another user",
+			collection: TaskAssignmentCollection,
+			existing:   []map[string]any{{"task_id": int32(1), "user_id": int32(2)}},
+			data:       map[string]any{"task_id": 1, "user_id": 3},
+		},
+		{
+			name:       "null key is not compared",
+			collection: InvoiceCollection,
+			existing:   []map[string]any{{"number": nil}},
+			data:       map[string]any{"number": nil},
+		},
+		{
+			name:       "collection without unique keys",
+			collection: TaskCollection,
+			existing:   []map[string]any{{"title": "A"}},
+			data:       map[string]any{"title": "A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewMemoryStorage()
+			for _, data := range tt.existing {
+				if _, err := s.Insert(ctx, tt.collection, data); err != nil {
+					t.Fatalf("Insert() existing error = %v", err)
+				}
+			}
+
+			_, err := s.Insert(ctx, tt.collection, tt.data)
+			if errors.Is(err, ErrDuplicate) != tt.duplicate {
+				t.Fatalf("Insert() error = %v, duplicate %v", err, tt.duplicate)
+			}
+			if !tt.duplicate && err != nil {
+				t.Fatalf("Insert() error = %v", err)
+			}
+
+			count, err := s.Count(ctx, tt.collection, nil)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			want := int64(len(tt.existing) + 1)
+			if tt.duplicate {
+				want--
+			}
+			if count != want {
+				t.Errorf("Count() = %d, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestUpdateUnique(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for _, code := range []string{"A1", "B1", "C1"} {
+		if _, err := s.Insert(ctx, ProjectCollection, map[string]any{"code": code, "active": true}); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		update    map[string]any
+		duplicate bool
+	}{
+		{name: "code of another project", filter: Match{"id": 1}, update: map[string]any{"code": "B1"}, duplicate: true},
+		{name: "same code to several projects", filter: In("id", 1, 2), update: map[string]any{"code": "D1"}, duplicate: true},
+		{name: "own code", filter: Match{"id": 1}, update: map[string]any{"code": "A1"}},
+		{name: "new code", filter: Match{"id": 3}, update: map[string]any{"code": "D1"}},
+		{name: "other fields of several projects", filter: In("id", 1, 2), update: map[string]any{"active": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Update(ctx, ProjectCollection, tt.filter, tt.update)
+			if errors.Is(err, ErrDuplicate) != tt.duplicate {
+				t.Fatalf("Update() error = %v, duplicate %v", err, tt.duplicate)
+			}
+			if !tt.duplicate && err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+		})
+	}
+
+	// Отклоненные изменения не применяются
+	reader, err := s.Select(ctx, ProjectCollection, nil, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	var codes []string
+	for reader.Next() {
+		record, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		codes = append(codes, record.Fields["code"].(string))
+	}
+	if want := []string{"A1", "B1", "D1"}; !slices.Equal(codes, want) {
+		t.Errorf("codes = %v, want %v", codes, want)
+	}
+}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS time_entries (
+    id         serial PRIMARY KEY,
+    task_id    int NOT NULL,
+    user_id    int NOT NULL,
+    started_at timestamp NOT NULL,
+    ended_at   timestamp,
+    note       varchar(500),
+    created timestamp default now()
+);
+
+CREATE INDEX IF NOT EXISTS time_entries_task_id_idx ON time_entries (task_id);
+CREATE INDEX IF NOT EXISTS time_entries_user_id_started_at_idx ON time_entries (user_id, started_at);
+
+-- начатые задачи продолжаются открытыми интервалами
+INSERT INTO time_entries (task_id, user_id, started_at)
+SELECT id, user_id, work_from
+FROM tasks
+WHERE work_from IS NOT NULL AND user_id IS NOT NULL;
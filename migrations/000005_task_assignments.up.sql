@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS task_assignments (
+    id      serial PRIMARY KEY,
+    task_id int NOT NULL,
+    user_id int NOT NULL,
+    created timestamp default now(),
+    UNIQUE (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_assignments_user_id_idx ON task_assignments (user_id);
+
+-- исполнители задач и участники интервалов работы становятся назначенными
+INSERT INTO task_assignments (task_id, user_id)
+SELECT id, user_id FROM tasks WHERE user_id IS NOT NULL AND user_id > 0
+UNION
+SELECT DISTINCT task_id, user_id FROM time_entries
+ON CONFLICT DO NOTHING;
+
+-- отсчет времени ведется интервалами работы каждого пользователя
+UPDATE tasks SET work_from = NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS workday_end;
+
+ALTER TABLE time_entries DROP COLUMN IF EXISTS auto_stopped;
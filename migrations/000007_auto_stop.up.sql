@@ -0,0 +1,4 @@
+ALTER TABLE time_entries ADD COLUMN IF NOT EXISTS auto_stopped boolean NOT NULL DEFAULT false;
+
+-- конец рабочего дня пользователя, "ЧЧ:ММ"
+ALTER TABLE users ADD COLUMN IF NOT EXISTS workday_end varchar(5);
@@ -0,0 +1,8 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS billable;
+ALTER TABLE tasks DROP COLUMN IF EXISTS hourly_rate;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS billable;
+ALTER TABLE projects DROP COLUMN IF EXISTS currency;
+ALTER TABLE projects DROP COLUMN IF EXISTS hourly_rate;
+
+ALTER TABLE users DROP COLUMN IF EXISTS hourly_rate;
@@ -0,0 +1,9 @@
+-- почасовые ставки: ставка задачи, иначе проекта, иначе пользователя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hourly_rate numeric(12,2);
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS hourly_rate numeric(12,2);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS currency varchar(3);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS billable boolean NOT NULL DEFAULT true;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS hourly_rate numeric(12,2);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS billable boolean NOT NULL DEFAULT true;
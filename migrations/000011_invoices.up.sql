@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    id          serial PRIMARY KEY,
+    number      varchar(20) NOT NULL,
+    client      varchar(100),
+    project_id  int,
+    period_from timestamp NOT NULL,
+    period_to   timestamp NOT NULL,
+    group_by    varchar(10) NOT NULL,
+    currency    varchar(3) NOT NULL,
+    seconds     bigint NOT NULL,
+    amount      numeric(16,2) NOT NULL,
+    created timestamp default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_number_idx ON invoices (number);
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id          serial PRIMARY KEY,
+    invoice_id  int NOT NULL,
+    task_id     int,
+    user_id     int,
+    description varchar(200) NOT NULL,
+    hourly_rate numeric(12,2) NOT NULL,
+    seconds     bigint NOT NULL,
+    amount      numeric(16,2) NOT NULL,
+    created timestamp default now()
+);
+
+CREATE INDEX IF NOT EXISTS invoice_lines_invoice_id_idx ON invoice_lines (invoice_id);
+
+-- интервал, вошедший в счет, больше не меняется
+ALTER TABLE time_entries ADD COLUMN IF NOT EXISTS invoice_id int;
+
+CREATE INDEX IF NOT EXISTS time_entries_invoice_id_idx ON time_entries (invoice_id);
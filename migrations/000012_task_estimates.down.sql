@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS estimate;
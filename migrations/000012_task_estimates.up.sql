@@ -0,0 +1,3 @@
+-- оценка трудоемкости задачи в наносекундах, как cost, и время завершения задачи
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate bigint;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at timestamp;
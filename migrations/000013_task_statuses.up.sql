@@ -0,0 +1,24 @@
+-- статус задачи: todo, in_progress, paused, done, cancelled
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'todo';
+
+-- завершенные задачи - done, задачи с незавершенными интервалами работы - in_progress,
+-- с завершенными - paused, как после остановки последнего таймера
+UPDATE tasks SET status = 'done' WHERE completed_at IS NOT NULL;
+UPDATE tasks SET status = 'in_progress'
+WHERE status = 'todo' AND EXISTS (SELECT 1 FROM time_entries WHERE task_id = tasks.id AND ended_at IS NULL);
+UPDATE tasks SET status = 'paused'
+WHERE status = 'todo' AND EXISTS (SELECT 1 FROM time_entries WHERE task_id = tasks.id);
+
+CREATE INDEX IF NOT EXISTS tasks_status_idx ON tasks (status);
+
+-- история смены статусов, для существующих задач начинается с этой миграции
+CREATE TABLE IF NOT EXISTS task_status_history (
+    id          serial PRIMARY KEY,
+    task_id     int NOT NULL,
+    from_status varchar(20),
+    to_status   varchar(20) NOT NULL,
+    note        varchar(500),
+    created timestamp default now()
+);
+
+CREATE INDEX IF NOT EXISTS task_status_history_task_id_idx ON task_status_history (task_id);
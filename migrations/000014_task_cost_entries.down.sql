@@ -0,0 +1 @@
+DELETE FROM time_entries WHERE note = 'time tracked before time entries';
@@ -0,0 +1,24 @@
+-- время задач, накопленное до интервалов работы (tasks.cost без времени завершенных интервалов и их пауз),
+-- переносится закрытым интервалом пользователя задачи, который заканчивается перед первым интервалом задачи
+-- или в момент миграции. Расхождение меньше секунды - погрешность округления.
+-- Время задач без пользователя остается только в tasks.cost
+INSERT INTO time_entries (task_id, user_id, started_at, ended_at, note)
+SELECT id, user_id, ended_at - (missing / 1e9)::double precision * interval '1 second', ended_at, 'time tracked before time entries'
+FROM (
+    SELECT t.id, t.user_id,
+           t.cost
+           - COALESCE((SELECT SUM(EXTRACT(EPOCH FROM e.ended_at - e.started_at))
+                       FROM time_entries e
+                       WHERE e.task_id = t.id AND e.ended_at IS NOT NULL), 0) * 1e9
+           + COALESCE((SELECT SUM(EXTRACT(EPOCH FROM p.ended_at - p.started_at))
+                       FROM time_entry_pauses p JOIN time_entries e ON e.id = p.entry_id
+                       WHERE e.task_id = t.id AND e.ended_at IS NOT NULL AND p.ended_at IS NOT NULL), 0) * 1e9 AS missing,
+           COALESCE((SELECT MIN(e.started_at) FROM time_entries e WHERE e.task_id = t.id), now() AT TIME ZONE 'UTC') AS ended_at
+    FROM tasks t
+    WHERE t.cost > 0 AND t.user_id IN (SELECT id FROM users)
+) backfill
+WHERE missing >= 1e9;
+
+-- задачи, по которым велась работа, отложены
+UPDATE tasks SET status = 'paused'
+WHERE status = 'todo' AND id IN (SELECT task_id FROM time_entries);
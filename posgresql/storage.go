@@ -6,29 +6,65 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	. "timetracking/storage"
+	"timetracking/storage/sqlquery"
 )
 
 var Logger = slog.Default()
 
 var _ Storage = (*PosgresqlStorage)(nil)
 
+var _ StatsProvider = (*PosgresqlStorage)(nil)
+
 type PsqlConfig struct {
 	Host     string
 	Port     int
 	Username string
 	Password string
 	Database string
+
+	// Настройки пула соединений, нулевые значения - значения по умолчанию pgxpool
+	MinConns          int32         // минимальное количество соединений
+	MaxConns          int32         // максимальное количество соединений
+	MaxConnIdleTime   time.Duration // время простоя, после которого соединение закрывается
+	HealthCheckPeriod time.Duration // период проверки простаивающих соединений
+}
+
+// PoolConfig - конфигурация пула соединений
+func (config *PsqlConfig) PoolConfig() (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.ConnInfo())
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	}
+	if config.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = config.HealthCheckPeriod
+	}
+
+	return poolConfig, nil
 }
 
 func (config *PsqlConfig) ConnInfo() string {
@@ -36,7 +72,23 @@ func (config *PsqlConfig) ConnInfo() string {
 }
 
 type PosgresqlStorage struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
+	tx pgx.Tx // текущая транзакция, nil вне транзакции
+}
+
+// querier - общие методы соединения и транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// querier - транзакция, если она начата, иначе пул соединений
+func (s *PosgresqlStorage) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
 }
 
 func migrating(pathMigrations string, connInfo string) error {
@@ -63,13 +115,26 @@ func NewPosgresqlStorage(config *PsqlConfig) (*PosgresqlStorage, error) {
 
 	Logger.Debug("posgresql: config", slog.String("config", fmt.Sprintf("%+v", config)))
 
-	db, err := pgx.Connect(context.Background(), config.ConnInfo())
+	poolConfig, err := config.PoolConfig()
+	if err != nil {
+		Logger.Info("posgresql: parse config failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("posgresql: parse config failed: %w", err)
+	}
+
+	db, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		Logger.Info("posgresql: connection failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("posgresql: connection failed: %w", err)
 	}
 
+	if err := db.Ping(context.Background()); err != nil {
+		db.Close()
+		Logger.Info("posgresql: connection failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("posgresql: connection failed: %w", err)
+	}
+
 	if err := migrating("file://migrations", config.ConnInfo()); err != nil {
+		db.Close()
 		Logger.Info("posgresql: migrating failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("posgresql: migrating failed: %w", err)
 	}
@@ -83,7 +148,23 @@ func NewPosgresqlStorage(config *PsqlConfig) (*PosgresqlStorage, error) {
 
 func (s *PosgresqlStorage) Close() error {
 	Logger.Info("posgresql: closing")
-	return s.db.Close(context.Background())
+	s.db.Close()
+	return nil
+}
+
+// Stats - статистика пула соединений
+func (s *PosgresqlStorage) Stats() PoolStats {
+	stat := s.db.Stat()
+
+	return PoolStats{
+		TotalConns:        stat.TotalConns(),
+		IdleConns:         stat.IdleConns(),
+		AcquiredConns:     stat.AcquiredConns(),
+		MaxConns:          stat.MaxConns(),
+		AcquireCount:      stat.AcquireCount(),
+		EmptyAcquireCount: stat.EmptyAcquireCount(),
+		AcquireDuration:   stat.AcquireDuration(),
+	}
 }
 
 type recordReader struct {
@@ -94,6 +175,20 @@ func (r *recordReader) Next() bool {
 	return r.rows.Next()
 }
 
+// Err - ошибка выполнения запроса, pgx сообщает ее только после окончания чтения
+func (r *recordReader) Err() error {
+	if err := r.rows.Err(); err != nil {
+		Logger.Info("posgresql: select failed", slog.String("error", err.Error()))
+		return fmt.Errorf("posgresql: select failed: %w", err)
+	}
+	return nil
+}
+
+// Close - закрыть выборку и вернуть соединение в пул
+func (r *recordReader) Close() {
+	r.rows.Close()
+}
+
 func (r *recordReader) Read() (*Record, error) {
 	if r.rows == nil {
 		return nil, sql.ErrNoRows
@@ -117,15 +212,27 @@ func (r *recordReader) Read() (*Record, error) {
 	}, nil
 }
 
-func (s *PosgresqlStorage) Select(collection string, filter map[string]any, limit, offset int) (RecordReader, error) {
-	Logger.Debug("posgresql: select", slog.String("collection", collection), slog.Any("filter", filter), slog.Int("limit", limit), slog.Int("offset", offset))
+func (s *PosgresqlStorage) Select(ctx context.Context, collection string, filter Filter, sort []Sort, limit, offset int) (RecordReader, error) {
+	Logger.Debug("posgresql: select", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("sort", sort), slog.Int("limit", limit), slog.Int("offset", offset))
+
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("posgresql: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("posgresql: select failed: %w", err)
+	}
+
+	order, err := sqlquery.OrderBy(sort)
+	if err != nil {
+		Logger.Info("posgresql: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("posgresql: select failed: %w", err)
+	}
 
-	exps := []goqu.Expression{}
-	for k, v := range filter {
-		exps = append(exps, goqu.I(k).Eq(v))
+	ds := goqu.From(collection).Where(exps...).Order(order...).Limit(uint(limit)).Offset(uint(offset))
+	if s.tx != nil {
+		ds = ds.ForUpdate(exp.Wait)
 	}
 
-	query, _, err := goqu.From(collection).Where(exps...).Limit(uint(limit)).Offset(uint(offset)).ToSQL()
+	query, _, err := ds.ToSQL()
 	if err != nil {
 		Logger.Info("posgresql: select failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("posgresql: select failed: %w", err)
@@ -133,7 +240,7 @@ func (s *PosgresqlStorage) Select(collection string, filter map[string]any, limi
 
 	Logger.Debug("posgresql: select", slog.String("query", query))
 
-	rows, err := s.db.Query(context.Background(), query)
+	rows, err := s.querier().Query(ctx, query)
 	if err != nil {
 		Logger.Info("posgresql: select failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("posgresql: select failed: %w", err)
@@ -144,12 +251,41 @@ func (s *PosgresqlStorage) Select(collection string, filter map[string]any, limi
 	return &recordReader{rows: rows}, nil
 }
 
-func (s *PosgresqlStorage) Update(collection string, filter map[string]any, update map[string]any) error {
+func (s *PosgresqlStorage) Count(ctx context.Context, collection string, filter Filter) (int64, error) {
+	Logger.Debug("posgresql: count", slog.String("collection", collection), slog.Any("filter", filter))
+
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("posgresql: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("posgresql: count failed: %w", err)
+	}
+
+	query, _, err := goqu.From(collection).Select(goqu.COUNT(goqu.Star())).Where(exps...).ToSQL()
+	if err != nil {
+		Logger.Info("posgresql: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("posgresql: count failed: %w", err)
+	}
+
+	Logger.Debug("posgresql: count", slog.String("query", query))
+
+	var count int64
+	err = s.querier().QueryRow(ctx, query).Scan(&count)
+	if err != nil {
+		Logger.Info("posgresql: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("posgresql: count failed: %w", err)
+	}
+
+	Logger.Debug("posgresql: count success", slog.Int64("count", count))
+	return count, nil
+}
+
+func (s *PosgresqlStorage) Update(ctx context.Context, collection string, filter Filter, update map[string]any) error {
 	Logger.Debug("posgresql: update", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("update", update))
 
-	exps := []goqu.Expression{}
-	for k, v := range filter {
-		exps = append(exps, goqu.I(k).Eq(v))
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("posgresql: update failed", slog.String("error", err.Error()))
+		return fmt.Errorf("posgresql: update failed: %w", err)
 	}
 	query, _, err := goqu.Update(collection).Set(update).Where(exps...).ToSQL()
 	if err != nil {
@@ -159,9 +295,12 @@ func (s *PosgresqlStorage) Update(collection string, filter map[string]any, upda
 
 	Logger.Debug("posgresql: update", slog.String("query", query))
 
-	_, err = s.db.Exec(context.Background(), query)
+	_, err = s.querier().Exec(ctx, query)
 	if err != nil {
 		Logger.Info("posgresql: update failed", slog.String("error", err.Error()))
+		if isDuplicate(err) {
+			return fmt.Errorf("posgresql: update failed: %w: %w", ErrDuplicate, err)
+		}
 		return fmt.Errorf("posgresql: update failed: %w", err)
 	}
 
@@ -170,7 +309,16 @@ func (s *PosgresqlStorage) Update(collection string, filter map[string]any, upda
 	return nil
 }
 
-func (s *PosgresqlStorage) Insert(collection string, data map[string]any) (int32, error) {
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+// isDuplicate - запись нарушила уникальный индекс
+func isDuplicate(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+func (s *PosgresqlStorage) Insert(ctx context.Context, collection string, data map[string]any) (int32, error) {
 	Logger.Debug("posgresql: insert", slog.String("collection", collection), slog.Any("data", data))
 
 	query, _, err := goqu.Insert(collection).Rows(data).Returning(goqu.C("id")).ToSQL()
@@ -182,9 +330,12 @@ func (s *PosgresqlStorage) Insert(collection string, data map[string]any) (int32
 	Logger.Debug("posgresql: insert", slog.String("query", query))
 
 	var id int32
-	err = s.db.QueryRow(context.Background(), query).Scan(&id)
+	err = s.querier().QueryRow(ctx, query).Scan(&id)
 	if err != nil {
 		Logger.Info("posgresql: insert failed", slog.String("error", err.Error()))
+		if isDuplicate(err) {
+			return 0, fmt.Errorf("posgresql: insert failed: %w: %w", ErrDuplicate, err)
+		}
 		return 0, fmt.Errorf("posgresql: insert failed: %w", err)
 	}
 
@@ -192,7 +343,7 @@ func (s *PosgresqlStorage) Insert(collection string, data map[string]any) (int32
 	return id, nil
 }
 
-func (s *PosgresqlStorage) Delete(collection string, id int32) error {
+func (s *PosgresqlStorage) Delete(ctx context.Context, collection string, id int32) error {
 	Logger.Debug("posgresql: delete", slog.String("collection", collection), slog.Int("id", int(id)))
 
 	query, _, err := goqu.Delete(collection).Where(goqu.C("id").Eq(id)).ToSQL()
@@ -203,7 +354,7 @@ func (s *PosgresqlStorage) Delete(collection string, id int32) error {
 
 	Logger.Debug("posgresql: delete", slog.String("query", query))
 
-	_, err = s.db.Exec(context.Background(), query)
+	_, err = s.querier().Exec(ctx, query)
 	if err != nil {
 		Logger.Info("posgresql: delete failed", slog.String("error", err.Error()))
 		return fmt.Errorf("posgresql: delete failed: %w", err)
@@ -213,3 +364,36 @@ func (s *PosgresqlStorage) Delete(collection string, id int32) error {
 
 	return nil
 }
+
+func (s *PosgresqlStorage) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	Logger.Debug("posgresql: begin transaction")
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		Logger.Info("posgresql: begin transaction failed", slog.String("error", err.Error()))
+		return fmt.Errorf("posgresql: begin transaction failed: %w", err)
+	}
+
+	if err := fn(&PosgresqlStorage{db: s.db, tx: tx}); err != nil {
+		Logger.Debug("posgresql: rollback transaction", slog.String("error", err.Error()))
+		// Откат не должен зависеть от отмены контекста запроса
+		if errRollback := tx.Rollback(context.Background()); errRollback != nil {
+			Logger.Info("posgresql: rollback transaction failed", slog.String("error", errRollback.Error()))
+			return errors.Join(err, fmt.Errorf("posgresql: rollback transaction failed: %w", errRollback))
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		Logger.Info("posgresql: commit transaction failed", slog.String("error", err.Error()))
+		return fmt.Errorf("posgresql: commit transaction failed: %w", err)
+	}
+
+	Logger.Debug("posgresql: commit transaction success")
+
+	return nil
+}
@@ -0,0 +1,7 @@
+DELETE FROM tasks
+WHERE id IN (
+    SELECT id
+    FROM tasks
+    ORDER BY created DESC
+    LIMIT 10
+);
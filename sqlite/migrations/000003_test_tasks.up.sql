@@ -0,0 +1,12 @@
+INSERT INTO tasks (title, description, period_from, period_to, user_id, cost)
+VALUES
+    ('Задача 1', 'Описание задачи 1', '2024-07-01 00:00:00.000000', '2024-07-05 00:00:00.000000', 0, 0),
+    ('Задача 2', 'Описание задачи 2', '2024-07-02 00:00:00.000000', '2024-07-06 00:00:00.000000', 0, 0),
+    ('Задача 3', 'Описание задачи 3', '2024-07-03 00:00:00.000000', '2024-07-07 00:00:00.000000', 0, 0),
+    ('Задача 4', 'Описание задачи 4', '2024-07-04 00:00:00.000000', '2024-07-08 00:00:00.000000', 0, 0),
+    ('Задача 5', 'Описание задачи 5', '2024-07-05 00:00:00.000000', '2024-07-09 00:00:00.000000', 0, 0),
+    ('Задача 6', 'Описание задачи 6', '2024-07-06 00:00:00.000000', '2024-07-10 00:00:00.000000', 0, 0),
+    ('Задача 7', 'Описание задачи 7', '2024-07-07 00:00:00.000000', '2024-07-11 00:00:00.000000', 0, 0),
+    ('Задача 8', 'Описание задачи 8', '2024-07-08 00:00:00.000000', '2024-07-12 00:00:00.000000', 0, 0),
+    ('Задача 9', 'Описание задачи 9', '2024-07-09 00:00:00.000000', '2024-07-13 00:00:00.000000', 0, 0),
+    ('Задача 10', 'Описание задачи 10', '2024-07-10 00:00:00.000000', '2024-07-14 00:00:00.000000', 0, 0);
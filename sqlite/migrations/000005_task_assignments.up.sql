@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS task_assignments (
+    id      integer PRIMARY KEY AUTOINCREMENT,
+    task_id int NOT NULL,
+    user_id int NOT NULL,
+    created timestamp default CURRENT_TIMESTAMP,
+    UNIQUE (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_assignments_user_id_idx ON task_assignments (user_id);
+
+-- исполнители задач и участники интервалов работы становятся назначенными
+INSERT OR IGNORE INTO task_assignments (task_id, user_id, created)
+SELECT task_id, user_id, strftime('%Y-%m-%d %H:%M:%f000', 'now')
+FROM (
+    SELECT id AS task_id, user_id FROM tasks WHERE user_id IS NOT NULL AND user_id > 0
+    UNION
+    SELECT task_id, user_id FROM time_entries
+);
+
+-- отсчет времени ведется интервалами работы каждого пользователя
+UPDATE tasks SET work_from = NULL;
@@ -0,0 +1 @@
+DROP TABLE time_entry_pauses;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS time_entry_pauses (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    entry_id   int NOT NULL,
+    started_at timestamp NOT NULL,
+    ended_at   timestamp,
+    created timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS time_entry_pauses_entry_id_idx ON time_entry_pauses (entry_id);
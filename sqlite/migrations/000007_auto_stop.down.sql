@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN workday_end;
+
+ALTER TABLE time_entries DROP COLUMN auto_stopped;
@@ -0,0 +1,4 @@
+ALTER TABLE time_entries ADD COLUMN auto_stopped boolean NOT NULL DEFAULT 0;
+
+-- конец рабочего дня пользователя, "ЧЧ:ММ"
+ALTER TABLE users ADD COLUMN workday_end varchar(5);
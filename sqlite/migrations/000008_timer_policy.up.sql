@@ -0,0 +1,2 @@
+-- политика одного таймера пользователя: reject или switch, NULL - общая настройка сервиса
+ALTER TABLE users ADD COLUMN timer_policy varchar(10);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    name        varchar(100) NOT NULL,
+    code        varchar(20) NOT NULL,
+    client      varchar(100),
+    active      boolean NOT NULL DEFAULT 1,
+    period_from timestamp,
+    period_to   timestamp,
+    created timestamp default CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS projects_code_idx ON projects (code);
+
+-- задача может принадлежать проекту
+ALTER TABLE tasks ADD COLUMN project_id int;
+
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (project_id);
@@ -0,0 +1,8 @@
+ALTER TABLE tasks DROP COLUMN billable;
+ALTER TABLE tasks DROP COLUMN hourly_rate;
+
+ALTER TABLE projects DROP COLUMN billable;
+ALTER TABLE projects DROP COLUMN currency;
+ALTER TABLE projects DROP COLUMN hourly_rate;
+
+ALTER TABLE users DROP COLUMN hourly_rate;
@@ -0,0 +1,10 @@
+-- почасовые ставки: ставка задачи, иначе проекта, иначе пользователя.
+-- Ставки хранятся строкой, чтобы SQLite не превращал их в числа с плавающей точкой
+ALTER TABLE users ADD COLUMN hourly_rate varchar(16);
+
+ALTER TABLE projects ADD COLUMN hourly_rate varchar(16);
+ALTER TABLE projects ADD COLUMN currency varchar(3);
+ALTER TABLE projects ADD COLUMN billable boolean NOT NULL DEFAULT 1;
+
+ALTER TABLE tasks ADD COLUMN hourly_rate varchar(16);
+ALTER TABLE tasks ADD COLUMN billable boolean NOT NULL DEFAULT 1;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS time_entries_invoice_id_idx;
+
+ALTER TABLE time_entries DROP COLUMN invoice_id;
+
+DROP TABLE IF EXISTS invoice_lines;
+
+DROP TABLE IF EXISTS invoices;
@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN estimate;
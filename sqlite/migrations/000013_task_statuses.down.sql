@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS task_status_history;
+
+DROP INDEX IF EXISTS tasks_status_idx;
+
+ALTER TABLE tasks DROP COLUMN status;
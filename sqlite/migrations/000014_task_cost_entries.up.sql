@@ -0,0 +1,28 @@
+-- время задач, накопленное до интервалов работы (tasks.cost без времени завершенных интервалов и их пауз),
+-- переносится закрытым интервалом пользователя задачи, который заканчивается перед первым интервалом задачи
+-- или в момент миграции, с точностью до миллисекунды. Расхождение меньше секунды - погрешность округления.
+-- Время задач без пользователя остается только в tasks.cost
+INSERT INTO time_entries (task_id, user_id, started_at, ended_at, note, created)
+SELECT id, user_id,
+       strftime('%Y-%m-%d %H:%M:%f000', ended_at, printf('-%.3f seconds', missing / 1e9)),
+       ended_at,
+       'time tracked before time entries',
+       strftime('%Y-%m-%d %H:%M:%f000', 'now')
+FROM (
+    SELECT t.id, t.user_id,
+           t.cost
+           - COALESCE((SELECT SUM(julianday(e.ended_at) - julianday(e.started_at))
+                       FROM time_entries e
+                       WHERE e.task_id = t.id AND e.ended_at IS NOT NULL), 0) * 86400e9
+           + COALESCE((SELECT SUM(julianday(p.ended_at) - julianday(p.started_at))
+                       FROM time_entry_pauses p JOIN time_entries e ON e.id = p.entry_id
+                       WHERE e.task_id = t.id AND e.ended_at IS NOT NULL AND p.ended_at IS NOT NULL), 0) * 86400e9 AS missing,
+           strftime('%Y-%m-%d %H:%M:%f000', COALESCE((SELECT MIN(e.started_at) FROM time_entries e WHERE e.task_id = t.id), 'now')) AS ended_at
+    FROM tasks t
+    WHERE t.cost > 0 AND t.user_id IN (SELECT id FROM users)
+)
+WHERE missing >= 1e9;
+
+-- задачи, по которым велась работа, отложены
+UPDATE tasks SET status = 'paused'
+WHERE status = 'todo' AND id IN (SELECT task_id FROM time_entries);
@@ -0,0 +1,3 @@
+-- паспорт определяет пользователя, параллельное создание не добавит второго пользователя.
+-- Если в базе уже есть пользователи с одинаковым паспортом, их нужно объединить до миграции
+CREATE UNIQUE INDEX IF NOT EXISTS users_passport_idx ON users (pasport_series, pasport_number);
@@ -0,0 +1,447 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	sqlite3driver "github.com/mattn/go-sqlite3"
+
+	. "timetracking/storage"
+	"timetracking/storage/sqlquery"
+)
+
+var Logger = slog.Default()
+
+var _ Storage = (*SqliteStorage)(nil)
+
+var _ StatsProvider = (*SqliteStorage)(nil)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Диалект sqlite3 с форматом времени, который сравнивается как строка
+// и читается драйвером как timestamp
+const dialectName = "timetracking-sqlite"
+
+const timeFormat = "2006-01-02 15:04:05.000000"
+
+func init() {
+	opts := sqlite3.DialectOptions()
+	opts.TimeFormat = timeFormat
+	goqu.RegisterDialect(dialectName, opts)
+}
+
+type SqliteConfig struct {
+	Path string // путь к файлу базы данных
+}
+
+func (config *SqliteConfig) ConnInfo() string {
+	// _txlock=immediate - транзакция сразу берет блокировку на запись,
+	// это заменяет SELECT ... FOR UPDATE, которого нет в sqlite
+	return config.Path + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+}
+
+type SqliteStorage struct {
+	db      *sql.DB
+	tx      *sql.Tx // текущая транзакция, nil вне транзакции
+	dialect goqu.DialectWrapper
+}
+
+// querier - общие методы соединения и транзакции
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier - транзакция, если она начата, иначе соединение
+func (s *SqliteStorage) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func migrating(connInfo string) error {
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("sqlite: migrate failed: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance(
+		"iofs",
+		source,
+		"sqlite3://"+connInfo,
+	)
+	if err != nil {
+		return fmt.Errorf("sqlite: migrate failed: %w", err)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("sqlite: migrate failed: %w", err)
+	}
+
+	return nil
+}
+
+func NewSqliteStorage(config *SqliteConfig) (*SqliteStorage, error) {
+	if config == nil || config.Path == "" {
+		Logger.Info("sqlite: config is empty")
+		return nil, errors.New("sqlite: config is empty")
+	}
+
+	Logger.Debug("sqlite: config", slog.String("config", fmt.Sprintf("%+v", config)))
+
+	if err := migrating(config.ConnInfo()); err != nil {
+		Logger.Info("sqlite: migrating failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: migrating failed: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", config.ConnInfo())
+	if err != nil {
+		Logger.Info("sqlite: connection failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: connection failed: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		Logger.Info("sqlite: connection failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: connection failed: %w", err)
+	}
+
+	Logger.Info("sqlite: connected")
+
+	return &SqliteStorage{
+		db:      db,
+		dialect: goqu.Dialect(dialectName),
+	}, nil
+}
+
+func (s *SqliteStorage) Close() error {
+	Logger.Info("sqlite: closing")
+	return s.db.Close()
+}
+
+// Stats - статистика пула соединений database/sql
+func (s *SqliteStorage) Stats() PoolStats {
+	stat := s.db.Stats()
+
+	return PoolStats{
+		TotalConns:        int32(stat.OpenConnections),
+		IdleConns:         int32(stat.Idle),
+		AcquiredConns:     int32(stat.InUse),
+		MaxConns:          int32(stat.MaxOpenConnections),
+		EmptyAcquireCount: stat.WaitCount,
+		AcquireDuration:   stat.WaitDuration,
+	}
+}
+
+type recordReader struct {
+	rows    *sql.Rows
+	columns []*sql.ColumnType
+}
+
+func (r *recordReader) Next() bool {
+	return r.rows.Next()
+}
+
+// Err - ошибка выполнения запроса во время чтения
+func (r *recordReader) Err() error {
+	if err := r.rows.Err(); err != nil {
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: select failed: %w", err)
+	}
+	return nil
+}
+
+// Close - закрыть выборку и освободить соединение
+func (r *recordReader) Close() {
+	r.rows.Close()
+}
+
+func (r *recordReader) Read() (*Record, error) {
+	if r.rows == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	rowData := make([]any, len(r.columns))
+	pointers := make([]any, len(r.columns))
+	for i := range rowData {
+		pointers[i] = &rowData[i]
+	}
+
+	if err := r.rows.Scan(pointers...); err != nil {
+		Logger.Info("sqlite: read failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: read failed: %w", err)
+	}
+
+	rowMap := map[string]any{}
+	for i, column := range r.columns {
+		value, err := convertValue(column.DatabaseTypeName(), rowData[i])
+		if err != nil {
+			Logger.Info("sqlite: read failed", slog.String("column", column.Name()), slog.String("error", err.Error()))
+			return nil, fmt.Errorf("sqlite: read failed: column %s: %w", column.Name(), err)
+		}
+		rowMap[column.Name()] = value
+	}
+
+	id, _ := rowMap["id"].(int32)
+
+	return &Record{
+		Id:     id,
+		Fields: rowMap,
+	}, nil
+}
+
+// convertValue - приведение значений sqlite к типам, которые возвращает posgresql:
+// int - int32, bigint - int64, varchar - string
+func convertValue(databaseType string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	databaseType = strings.ToUpper(databaseType)
+	switch {
+	case databaseType == "INT" || databaseType == "INTEGER":
+		v, ok := value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %T for %s", value, databaseType)
+		}
+		if v != int64(int32(v)) {
+			return nil, fmt.Errorf("value %d overflows int32", v)
+		}
+		return int32(v), nil
+
+	case strings.HasPrefix(databaseType, "VARCHAR") || databaseType == "TEXT":
+		if v, ok := value.([]byte); ok {
+			return string(v), nil
+		}
+	}
+
+	return value, nil
+}
+
+func (s *SqliteStorage) Select(ctx context.Context, collection string, filter Filter, sort []Sort, limit, offset int) (RecordReader, error) {
+	Logger.Debug("sqlite: select", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("sort", sort), slog.Int("limit", limit), slog.Int("offset", offset))
+
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: select failed: %w", err)
+	}
+
+	order, err := sqlquery.OrderBy(sort)
+	if err != nil {
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: select failed: %w", err)
+	}
+
+	// sqlite не допускает OFFSET без LIMIT
+	if limit == 0 && offset > 0 {
+		limit = math.MaxInt64
+	}
+
+	query, _, err := s.dialect.From(collection).Where(exps...).Order(order...).Limit(uint(limit)).Offset(uint(offset)).ToSQL()
+	if err != nil {
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: select failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: select", slog.String("query", query))
+
+	rows, err := s.querier().QueryContext(ctx, query)
+	if err != nil {
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: select failed: %w", err)
+	}
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		Logger.Info("sqlite: select failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("sqlite: select failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: select success")
+
+	return &recordReader{rows: rows, columns: columns}, nil
+}
+
+func (s *SqliteStorage) Count(ctx context.Context, collection string, filter Filter) (int64, error) {
+	Logger.Debug("sqlite: count", slog.String("collection", collection), slog.Any("filter", filter))
+
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("sqlite: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: count failed: %w", err)
+	}
+
+	query, _, err := s.dialect.From(collection).Select(goqu.COUNT(goqu.Star())).Where(exps...).ToSQL()
+	if err != nil {
+		Logger.Info("sqlite: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: count failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: count", slog.String("query", query))
+
+	rows, err := s.querier().QueryContext(ctx, query)
+	if err != nil {
+		Logger.Info("sqlite: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: count failed: %w", err)
+	}
+	defer rows.Close()
+
+	var count int64
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		Logger.Info("sqlite: count failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: count failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: count success", slog.Int64("count", count))
+	return count, nil
+}
+
+func (s *SqliteStorage) Update(ctx context.Context, collection string, filter Filter, update map[string]any) error {
+	Logger.Debug("sqlite: update", slog.String("collection", collection), slog.Any("filter", filter), slog.Any("update", update))
+
+	exps, err := sqlquery.Where(filter)
+	if err != nil {
+		Logger.Info("sqlite: update failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: update failed: %w", err)
+	}
+	query, _, err := s.dialect.Update(collection).Set(update).Where(exps...).ToSQL()
+	if err != nil {
+		Logger.Info("sqlite: update failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: update failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: update", slog.String("query", query))
+
+	_, err = s.querier().ExecContext(ctx, query)
+	if err != nil {
+		Logger.Info("sqlite: update failed", slog.String("error", err.Error()))
+		if isDuplicate(err) {
+			return fmt.Errorf("sqlite: update failed: %w: %w", ErrDuplicate, err)
+		}
+		return fmt.Errorf("sqlite: update failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: update success")
+
+	return nil
+}
+
+// isDuplicate - запись нарушила уникальный индекс
+func isDuplicate(err error) bool {
+	var sqliteErr sqlite3driver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3driver.ErrConstraintUnique
+}
+
+func (s *SqliteStorage) Insert(ctx context.Context, collection string, data map[string]any) (int32, error) {
+	Logger.Debug("sqlite: insert", slog.String("collection", collection), slog.Any("data", data))
+
+	// CURRENT_TIMESTAMP хранит время без долей секунды, и такие значения не равны
+	// строкам в timeFormat при сравнении, поэтому created заполняется здесь
+	if _, ok := data["created"]; !ok {
+		data = maps.Clone(data)
+		data["created"] = time.Now().UTC()
+	}
+
+	// sqlite3 в goqu не поддерживает RETURNING, идентификатор берется из результата
+	query, _, err := s.dialect.Insert(collection).Rows(data).ToSQL()
+	if err != nil {
+		Logger.Info("sqlite: insert failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: insert failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: insert", slog.String("query", query))
+
+	result, err := s.querier().ExecContext(ctx, query)
+	if err != nil {
+		Logger.Info("sqlite: insert failed", slog.String("error", err.Error()))
+		if isDuplicate(err) {
+			return 0, fmt.Errorf("sqlite: insert failed: %w: %w", ErrDuplicate, err)
+		}
+		return 0, fmt.Errorf("sqlite: insert failed: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		Logger.Info("sqlite: insert failed", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("sqlite: insert failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: insert success")
+	return int32(id), nil
+}
+
+func (s *SqliteStorage) Delete(ctx context.Context, collection string, id int32) error {
+	Logger.Debug("sqlite: delete", slog.String("collection", collection), slog.Int("id", int(id)))
+
+	query, _, err := s.dialect.Delete(collection).Where(goqu.C("id").Eq(id)).ToSQL()
+	if err != nil {
+		Logger.Info("sqlite: delete failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: delete failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: delete", slog.String("query", query))
+
+	_, err = s.querier().ExecContext(ctx, query)
+	if err != nil {
+		Logger.Info("sqlite: delete failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: delete failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: delete success")
+
+	return nil
+}
+
+func (s *SqliteStorage) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	Logger.Debug("sqlite: begin transaction")
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		Logger.Info("sqlite: begin transaction failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: begin transaction failed: %w", err)
+	}
+
+	if err := fn(&SqliteStorage{db: s.db, tx: tx, dialect: s.dialect}); err != nil {
+		Logger.Debug("sqlite: rollback transaction", slog.String("error", err.Error()))
+		if errRollback := tx.Rollback(); errRollback != nil {
+			Logger.Info("sqlite: rollback transaction failed", slog.String("error", errRollback.Error()))
+			return errors.Join(err, fmt.Errorf("sqlite: rollback transaction failed: %w", errRollback))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		Logger.Info("sqlite: commit transaction failed", slog.String("error", err.Error()))
+		return fmt.Errorf("sqlite: commit transaction failed: %w", err)
+	}
+
+	Logger.Debug("sqlite: commit transaction success")
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor - позиция в списке записей, упорядоченном по (created, id).
+// Следующая страница выбирается условием по ключу вместо OFFSET,
+// поэтому не замедляется на больших таблицах и не сдвигается при вставках
+type Cursor struct {
+	Created time.Time `json:"c"`
+	Id      int32     `json:"i"`
+	Desc    bool      `json:"d,omitempty"`
+}
+
+// CursorSort - порядок записей для чтения по курсору
+func CursorSort(desc bool) []Sort {
+	return []Sort{{Field: "created", Desc: desc}, {Field: "id", Desc: desc}}
+}
+
+// Filter - записи, идущие после курсора в порядке CursorSort
+func (c Cursor) Filter() Filter {
+	after := Gt
+	if c.Desc {
+		after = Lt
+	}
+	return Or(
+		after("created", c.Created),
+		And(Eq("created", c.Created), after("id", c.Id)),
+	)
+}
+
+// Encode - непрозрачное строковое представление курсора для передачи клиенту
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor - разбор курсора, полученного от Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.Created.IsZero() || c.Id <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+// Operator - оператор условия фильтра
+type Operator string
+
+const (
+	OpEq     Operator = "eq"     // равно, Eq(field, nil) - IS NULL
+	OpNeq    Operator = "neq"    // не равно
+	OpGt     Operator = "gt"     // больше
+	OpGte    Operator = "gte"    // больше или равно
+	OpLt     Operator = "lt"     // меньше
+	OpLte    Operator = "lte"    // меньше или равно
+	OpIn     Operator = "in"     // входит в список, Value - срез значений
+	OpLike   Operator = "like"   // соответствует шаблону LIKE (% и _)
+	OpIsNull Operator = "isnull" // Value - bool: true - IS NULL, false - IS NOT NULL
+)
+
+// Filter - выражение фильтра: Condition, Group или Match. nil - без условий
+type Filter interface {
+	isFilter()
+}
+
+// Condition - условие на одно поле
+type Condition struct {
+	Field    string
+	Operator Operator
+	Value    any
+}
+
+// Group - условия, объединенные через AND (по умолчанию) или OR
+type Group struct {
+	Or      bool
+	Filters []Filter
+}
+
+// Match - равенство всех полей
+type Match map[string]any
+
+func (Condition) isFilter() {}
+func (Group) isFilter()     {}
+func (Match) isFilter()     {}
+
+func Eq(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpEq, Value: value}
+}
+
+func Neq(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpNeq, Value: value}
+}
+
+func Gt(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpGt, Value: value}
+}
+
+func Gte(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpGte, Value: value}
+}
+
+func Lt(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpLt, Value: value}
+}
+
+func Lte(field string, value any) Condition {
+	return Condition{Field: field, Operator: OpLte, Value: value}
+}
+
+func In[T any](field string, values ...T) Condition {
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return Condition{Field: field, Operator: OpIn, Value: list}
+}
+
+func Like(field string, pattern string) Condition {
+	return Condition{Field: field, Operator: OpLike, Value: pattern}
+}
+
+func IsNull(field string) Condition {
+	return Condition{Field: field, Operator: OpIsNull, Value: true}
+}
+
+func IsNotNull(field string) Condition {
+	return Condition{Field: field, Operator: OpIsNull, Value: false}
+}
+
+// And - все условия должны выполняться, пустая группа - без условий
+func And(filters ...Filter) Group {
+	return Group{Filters: filters}
+}
+
+// Or - хотя бы одно условие должно выполняться, пустая группа - ни одной записи
+func Or(filters ...Filter) Group {
+	return Group{Or: true, Filters: filters}
+}
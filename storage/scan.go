@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ScanError - значение колонки не подходит к полю структуры
+type ScanError struct {
+	Column string
+	Field  string
+	Err    error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("storage: scan column %s into %s failed: %s", e.Column, e.Field, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// TypedReader - чтение записей в структуры T.
+// Поля T связываются с колонками тегом db:"колонка", поля без тега пропускаются.
+// NULL или отсутствующая колонка допустимы только для полей-указателей (nil),
+// полей с тегом db:"колонка,null" (нулевое значение) и полей, реализующих sql.Scanner.
+// Числа приводятся между типами с проверкой диапазона, значения driver.Valuer
+// сначала заменяются результатом Value, остальные несовпадения типов - ScanError
+type TypedReader[T any] struct {
+	reader RecordReader
+}
+
+func NewTypedReader[T any](reader RecordReader) *TypedReader[T] {
+	return &TypedReader[T]{reader: reader}
+}
+
+func (r *TypedReader[T]) Next() bool {
+	return r.reader.Next()
+}
+
+func (r *TypedReader[T]) Err() error {
+	return r.reader.Err()
+}
+
+func (r *TypedReader[T]) Close() {
+	r.reader.Close()
+}
+
+func (r *TypedReader[T]) Read() (*T, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return ScanRecord[T](record)
+}
+
+// ReadAll - прочитать все записи в структуры T, выборка закрывается.
+// Ошибка запроса, полученная во время чтения, возвращается вместо неполного результата
+func ReadAll[T any](reader RecordReader) ([]*T, error) {
+	typed := NewTypedReader[T](reader)
+	defer typed.Close()
+
+	var items []*T
+	for typed.Next() {
+		item, err := typed.Read()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := typed.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ScanRecord - заполнить новую структуру T полями записи
+func ScanRecord[T any](record *Record) (*T, error) {
+	item := new(T)
+
+	value := reflect.ValueOf(item).Elem()
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("storage: scan into %T failed: not a struct", *item)
+	}
+
+	for _, field := range structFields(value.Type()) {
+		target := value.Field(field.index)
+		if err := assign(target, record.Fields[field.column], field.nullable); err != nil {
+			return nil, &ScanError{
+				Column: field.column,
+				Field:  value.Type().Name() + "." + value.Type().Field(field.index).Name,
+				Err:    err,
+			}
+		}
+	}
+
+	return item, nil
+}
+
+type structField struct {
+	index    int
+	column   string
+	nullable bool
+}
+
+// fieldsCache - разобранные теги структур по типу
+var fieldsCache sync.Map
+
+func structFields(t reflect.Type) []structField {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("db")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+
+		column, options, _ := strings.Cut(tag, ",")
+		fields = append(fields, structField{
+			index:    i,
+			column:   column,
+			nullable: options == "null",
+		})
+	}
+
+	fieldsCache.Store(t, fields)
+	return fields
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+func assign(target reflect.Value, value any, nullable bool) error {
+	// Значения драйвера (например numeric pgx) приводятся к простым типам, как их получил бы sql.Scanner
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	if target.CanAddr() && target.Addr().Type().Implements(scannerType) {
+		return target.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+
+	if value == nil {
+		if target.Kind() == reflect.Pointer || nullable {
+			target.SetZero()
+			return nil
+		}
+		return fmt.Errorf("unexpected NULL")
+	}
+
+	if target.Kind() == reflect.Pointer {
+		elem := reflect.New(target.Type().Elem())
+		if err := assign(elem.Elem(), value, false); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	}
+
+	source := reflect.ValueOf(value)
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(source)
+		if err != nil {
+			return err
+		}
+		if target.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, target.Type())
+		}
+		target.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(source)
+		if err != nil {
+			return err
+		}
+		if n < 0 || target.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, target.Type())
+		}
+		target.SetUint(uint64(n))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch {
+		case source.CanInt():
+			f = float64(source.Int())
+		case source.CanUint():
+			f = float64(source.Uint())
+		case source.CanFloat():
+			f = source.Float()
+		default:
+			return fmt.Errorf("cannot convert %T to %s", value, target.Type())
+		}
+		if target.OverflowFloat(f) {
+			return fmt.Errorf("value %v overflows %s", f, target.Type())
+		}
+		target.SetFloat(f)
+		return nil
+
+	case reflect.Bool:
+		switch {
+		case source.Kind() == reflect.Bool:
+			target.SetBool(source.Bool())
+			return nil
+		case source.CanInt() && (source.Int() == 0 || source.Int() == 1):
+			target.SetBool(source.Int() == 1)
+			return nil
+		}
+		return fmt.Errorf("cannot convert %T(%v) to %s", value, value, target.Type())
+
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			target.SetString(v)
+			return nil
+		case []byte:
+			target.SetString(string(v))
+			return nil
+		}
+		return fmt.Errorf("cannot convert %T to %s", value, target.Type())
+	}
+
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %T to %s", value, target.Type())
+}
+
+// toInt64 - целое значение числа, дробные числа и числа вне диапазона int64 - ошибка
+func toInt64(source reflect.Value) (int64, error) {
+	switch {
+	case source.CanInt():
+		return source.Int(), nil
+
+	case source.CanUint():
+		if source.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int64", source.Uint())
+		}
+		return int64(source.Uint()), nil
+
+	case source.CanFloat():
+		f := source.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", f)
+		}
+		return int64(f), nil
+	}
+
+	return 0, fmt.Errorf("cannot convert %s to integer", source.Type())
+}
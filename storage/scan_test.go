@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type scanItem struct {
+	Id      int32          `db:"id"`
+	Title   string         `db:"title"`
+	Note    string         `db:"note,null"`
+	Cost    time.Duration  `db:"cost,null"`
+	Ratio   float64        `db:"ratio,null"`
+	Done    bool           `db:"done,null"`
+	EndedAt *time.Time     `db:"ended_at"`
+	Count   uint8          `db:"count,null"`
+	Comment sql.NullString `db:"comment"`
+	Skipped string
+	Ignored string `db:"-"`
+}
+
+func TestScanRecord(t *testing.T) {
+	ended := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		fields map[string]any
+		want   scanItem
+		column string // колонка с ошибкой, пусто - без ошибки
+	}{
+		{
+			name:   "all fields",
+			fields: map[string]any{"id": int64(1), "title": "Задача", "note": "заметка", "cost": int64(time.Hour), "ratio": int32(2), "done": true, "ended_at": ended, "count": int64(3), "comment": "комментарий"},
+			want:   scanItem{Id: 1, Title: "Задача", Note: "заметка", Cost: time.Hour, Ratio: 2, Done: true, EndedAt: &ended, Count: 3, Comment: sql.NullString{String: "комментарий", Valid: true}},
+		},
+		{
+			name:   "null and missing nullable columns",
+			fields: map[string]any{"id": int32(2), "title": []byte("Задача"), "note": nil, "ended_at": nil},
+			want:   scanItem{Id: 2, Title: "Задача"},
+		},
+		{
+			name:   "sqlite booleans and floats",
+			fields: map[string]any{"id": 3.0, "title": "", "done": int64(1), "ratio": 0.5},
+			want:   scanItem{Id: 3, Done: true, Ratio: 0.5},
+		},
+		{
+			name:   "null in not nullable column",
+			fields: map[string]any{"id": nil, "title": ""},
+			column: "id",
+		},
+		{
+			name:   "missing not nullable column",
+			fields: map[string]any{"id": int32(1)},
+			column: "title",
+		},
+		{
+			name:   "int overflow",
+			fields: map[string]any{"id": int64(1) << 40, "title": ""},
+			column: "id",
+		},
+		{
+			name:   "negative unsigned",
+			fields: map[string]any{"id": int32(1), "title": "", "count": int64(-1)},
+			column: "count",
+		},
+		{
+			name:   "fractional int",
+			fields: map[string]any{"id": 1.5, "title": ""},
+			column: "id",
+		},
+		{
+			name:   "bool out of range",
+			fields: map[string]any{"id": int32(1), "title": "", "done": int64(2)},
+			column: "done",
+		},
+		{
+			name:   "string from number",
+			fields: map[string]any{"id": int32(1), "title": 5},
+			column: "title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScanRecord[scanItem](&Record{Fields: tt.fields})
+			if tt.column != "" {
+				var scanErr *ScanError
+				if !errors.As(err, &scanErr) {
+					t.Fatalf("ScanRecord() error = %v, want ScanError", err)
+				}
+				if scanErr.Column != tt.column {
+					t.Errorf("ScanRecord() error column = %s, want %s", scanErr.Column, tt.column)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScanRecord() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ScanRecord() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanRecordNotStruct(t *testing.T) {
+	_, err := ScanRecord[int](&Record{Fields: map[string]any{}})
+	if err == nil {
+		t.Fatal("ScanRecord[int]() error = nil, want error")
+	}
+}
@@ -0,0 +1,131 @@
+// Package sqlquery - построение sql-запросов goqu по описаниям из пакета storage,
+// общее для sql-хранилищ
+package sqlquery
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+
+	. "timetracking/storage"
+)
+
+// alwaysFalse - условие, которому не соответствует ни одна запись
+var alwaysFalse = goqu.L("1 = 0")
+
+// Where - условия WHERE для фильтра, nil фильтр - без условий
+func Where(filter Filter) ([]exp.Expression, error) {
+	if filter == nil {
+		return nil, nil
+	}
+
+	expression, err := expression(filter)
+	if err != nil {
+		return nil, err
+	}
+	if expression == nil {
+		return nil, nil
+	}
+
+	return []exp.Expression{expression}, nil
+}
+
+func expression(filter Filter) (exp.Expression, error) {
+	switch f := filter.(type) {
+	case nil:
+		return nil, nil
+
+	case Match:
+		// порядок полей фиксирован, чтобы запрос не менялся от запуска к запуску
+		fields := make([]string, 0, len(f))
+		for field := range f {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		exps := make([]exp.Expression, 0, len(fields))
+		for _, field := range fields {
+			exps = append(exps, goqu.I(field).Eq(f[field]))
+		}
+		if len(exps) == 0 {
+			return nil, nil
+		}
+		return goqu.And(exps...), nil
+
+	case Group:
+		exps := make([]exp.Expression, 0, len(f.Filters))
+		for _, item := range f.Filters {
+			e, err := expression(item)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				exps = append(exps, e)
+			}
+		}
+		if f.Or {
+			if len(exps) == 0 {
+				return alwaysFalse, nil
+			}
+			return goqu.Or(exps...), nil
+		}
+		if len(exps) == 0 {
+			return nil, nil
+		}
+		return goqu.And(exps...), nil
+
+	case Condition:
+		return condition(f)
+	}
+
+	return nil, fmt.Errorf("unknown filter %T", filter)
+}
+
+func condition(c Condition) (exp.Expression, error) {
+	if c.Field == "" {
+		return nil, fmt.Errorf("empty field in condition %s", c.Operator)
+	}
+
+	field := goqu.I(c.Field)
+
+	switch c.Operator {
+	case OpEq:
+		return field.Eq(c.Value), nil
+	case OpNeq:
+		return field.Neq(c.Value), nil
+	case OpGt:
+		return field.Gt(c.Value), nil
+	case OpGte:
+		return field.Gte(c.Value), nil
+	case OpLt:
+		return field.Lt(c.Value), nil
+	case OpLte:
+		return field.Lte(c.Value), nil
+	case OpLike:
+		return field.Like(c.Value), nil
+
+	case OpIn:
+		values, ok := c.Value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("field %s: in expects []any, got %T", c.Field, c.Value)
+		}
+		if len(values) == 0 {
+			return alwaysFalse, nil
+		}
+		return field.In(values...), nil
+
+	case OpIsNull:
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("field %s: isnull expects bool, got %T", c.Field, c.Value)
+		}
+		if isNull {
+			return field.IsNull(), nil
+		}
+		return field.IsNotNull(), nil
+	}
+
+	return nil, fmt.Errorf("field %s: unknown operator %q", c.Field, c.Operator)
+}
@@ -0,0 +1,28 @@
+package sqlquery
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+
+	. "timetracking/storage"
+)
+
+// OrderBy - выражения ORDER BY для сортировки, NULL всегда в конце
+func OrderBy(sort []Sort) ([]exp.OrderedExpression, error) {
+	order := make([]exp.OrderedExpression, 0, len(sort))
+	for _, s := range sort {
+		if s.Field == "" {
+			return nil, fmt.Errorf("empty sort field")
+		}
+
+		field := goqu.I(s.Field)
+		if s.Desc {
+			order = append(order, field.Desc().NullsLast())
+		} else {
+			order = append(order, field.Asc().NullsLast())
+		}
+	}
+	return order, nil
+}
@@ -1,30 +1,95 @@
 package storage
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
 const UserCollection = "users"
 
 const TaskCollection = "tasks"
 
+// TimeEntryCollection - интервалы работы пользователей над задачами
+const TimeEntryCollection = "time_entries"
+
+// TimeEntryPauseCollection - паузы внутри интервалов работы
+const TimeEntryPauseCollection = "time_entry_pauses"
+
+// TaskAssignmentCollection - назначения пользователей на задачи
+const TaskAssignmentCollection = "task_assignments"
+
+// ProjectCollection - проекты, объединяющие задачи
+const ProjectCollection = "projects"
+
+// InvoiceCollection - счета за оплачиваемое время
+const InvoiceCollection = "invoices"
+
+// InvoiceLineCollection - строки счетов
+const InvoiceLineCollection = "invoice_lines"
+
+// TaskStatusHistoryCollection - история смены статусов задач
+const TaskStatusHistoryCollection = "task_status_history"
+
+// ErrDuplicate - запись нарушает уникальный индекс коллекции
+var ErrDuplicate = errors.New("storage: duplicate record")
+
 type Record struct {
 	Collection string
 	Id         int32
 	Fields     map[string]any
 }
 
+// Sort - сортировка по полю, NULL всегда в конце
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// RecordReader - чтение записей выборки. После чтения нужно вызвать Close,
+// ошибка выполнения запроса возвращается из Err, когда Next вернул false
 type RecordReader interface {
 	Next() bool
 	Read() (*Record, error)
+	Err() error
+	Close()
 }
 
+// Storage - хранилище записей. Все методы прерываются при отмене ctx
 type Storage interface {
-	// Select - получить записи по фильтру с пагинацией
-	Select(collection string, filter map[string]any, limit, offset int) (RecordReader, error)
+	// Select - получить записи по фильтру с сортировкой и пагинацией
+	Select(ctx context.Context, collection string, filter Filter, sort []Sort, limit, offset int) (RecordReader, error)
 
-	// Update - обновить запись
-	Update(collection string, filter map[string]any, update map[string]any) error
+	// Count - количество записей по фильтру
+	Count(ctx context.Context, collection string, filter Filter) (int64, error)
 
-	// Insert - добавить запись, возвращает идентификатор
-	Insert(collection string, data map[string]any) (int32, error)
+	// Update - обновить записи по фильтру. Нарушение уникального индекса - ErrDuplicate
+	Update(ctx context.Context, collection string, filter Filter, update map[string]any) error
+
+	// Insert - добавить запись, возвращает идентификатор. Нарушение уникального индекса - ErrDuplicate
+	Insert(ctx context.Context, collection string, data map[string]any) (int32, error)
 
 	// Delete - удалить запись по идентификатору
-	Delete(collection string, id int32) error
+	Delete(ctx context.Context, collection string, id int32) error
+
+	// WithTx - выполнить fn в транзакции: при ошибке изменения откатываются,
+	// иначе фиксируются. Select внутри транзакции блокирует выбранные записи
+	// до ее завершения (SELECT ... FOR UPDATE). Вложенный вызов использует текущую транзакцию
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
+}
+
+// PoolStats - статистика пула соединений хранилища
+type PoolStats struct {
+	TotalConns        int32         `json:"totalConns"`                            // всего открытых соединений
+	IdleConns         int32         `json:"idleConns"`                             // простаивающих соединений
+	AcquiredConns     int32         `json:"acquiredConns"`                         // занятых соединений
+	MaxConns          int32         `json:"maxConns"`                              // максимум соединений, 0 - без ограничения
+	AcquireCount      int64         `json:"acquireCount"`                          // сколько раз соединение было получено из пула
+	EmptyAcquireCount int64         `json:"emptyAcquireCount"`                     // сколько раз пришлось ждать соединение
+	AcquireDuration   time.Duration `json:"acquireDuration" swaggertype:"integer"` // суммарное время ожидания соединений
+}
+
+// StatsProvider - хранилище, которое может сообщить статистику пула соединений
+type StatsProvider interface {
+	Stats() PoolStats
 }
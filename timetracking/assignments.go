@@ -0,0 +1,215 @@
+package timetracking
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// Назначение пользователя на задачу
+type TaskAssignment struct {
+	Id     int32 `json:"id" db:"id"`
+	TaskId int32 `json:"taskId" db:"task_id"` // идентификатор задачи
+	UserId int32 `json:"userId" db:"user_id"` // идентификатор пользователя
+
+	Created time.Time `json:"created" db:"created"` // дата назначения
+}
+
+// Время работы пользователя над задачей
+type TaskUserCost struct {
+	UserId     int32      `json:"userId"`               // идентификатор пользователя
+	Surname    string     `json:"surname"`              // фамилия
+	Name       string     `json:"name"`                 // имя
+	Patronymic string     `json:"patronymic,omitempty"` // отчество
+	Assigned   bool       `json:"assigned"`             // пользователь назначен на задачу
+	Seconds    int64      `json:"seconds"`              // затраченное время в секундах
+	Duration   string     `json:"duration"`             // затраченное время, например "1h 05m 09s"
+	Running    bool       `json:"running"`              // время пользователя по задаче идет
+	Timer      TimerState `json:"timer"`                // состояние отсчета времени пользователя
+
+	spent time.Duration
+}
+
+// findAssignments - назначения по фильтру
+func (s *TimeTrackingService) findAssignments(ctx context.Context, filter Filter) ([]*TaskAssignment, error) {
+	reader, err := s.storage.Select(ctx, TaskAssignmentCollection, filter, []Sort{{Field: "id"}}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll[TaskAssignment](reader)
+}
+
+// assign - назначить пользователя на задачу, если он еще не назначен
+func (s *TimeTrackingService) assign(ctx context.Context, taskId, userId int32) error {
+	assignments, err := s.findAssignments(ctx, Match{"task_id": taskId, "user_id": userId})
+	if err != nil {
+		return err
+	}
+	if len(assignments) > 0 {
+		return nil
+	}
+
+	_, err = s.storage.Insert(ctx, TaskAssignmentCollection, map[string]any{
+		"task_id": taskId,
+		"user_id": userId,
+	})
+	return err
+}
+
+// Назначение пользователя на задачу
+func (s *TimeTrackingService) AssignTask(ctx context.Context, pasportSeries, pasportNumber string, taskId int32) error {
+	const op = "TimeTrackingService: AssignTask"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, taskId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.assign(ctx, task.Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: AssignTask user assigned", slog.Int("userId", int(user.Id)), slog.Int("taskId", int(task.Id)))
+		return nil
+	})
+}
+
+// Снятие пользователя с задачи, пока отсчет его времени по задаче идет, снять нельзя
+func (s *TimeTrackingService) UnassignTask(ctx context.Context, pasportSeries, pasportNumber string, taskId int32) error {
+	const op = "TimeTrackingService: UnassignTask"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, taskId)
+		if err != nil {
+			return err
+		}
+
+		entry, err := tx.openTimeEntry(ctx, task.Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+		if entry != nil {
+			Logger.Info(op+" failed", slog.String("error", "task is started by user"))
+			return &ConflictError{"task is started by user, end it before unassigning"}
+		}
+
+		assignments, err := tx.findAssignments(ctx, Match{"task_id": task.Id, "user_id": user.Id})
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+		if len(assignments) == 0 {
+			Logger.Info(op+" failed", slog.String("error", "user is not assigned"))
+			return &NotFoundError{"user is not assigned to the task"}
+		}
+
+		for _, assignment := range assignments {
+			err = tx.storage.Delete(ctx, TaskAssignmentCollection, assignment.Id)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		Logger.Debug("TimeTrackingService: UnassignTask user unassigned", slog.Int("userId", int(user.Id)), slog.Int("taskId", int(task.Id)))
+		return nil
+	})
+}
+
+// Время работы над задачей по пользователям: назначенные пользователи и все,
+// кто работал над задачей, по убыванию затраченного времени
+func (s *TimeTrackingService) TaskUsersCost(ctx context.Context, taskId int32) ([]*TaskUserCost, error) {
+	const op = "TimeTrackingService: TaskUsersCost"
+
+	Logger.Debug(op, slog.Int("taskId", int(taskId)))
+
+	task, err := s.FindTaskById(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.findAssignments(ctx, Eq("task_id", task.Id))
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	entries, err := s.FindTimeEntriesByFilter(ctx, Eq("task_id", task.Id), nil, Pagination{})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	// Подсчет затраченного времени по пользователям
+	var userIds []int32
+	costs := map[int32]*TaskUserCost{}
+	costOf := func(userId int32) *TaskUserCost {
+		cost, ok := costs[userId]
+		if !ok {
+			cost = &TaskUserCost{UserId: userId, Timer: TimerStopped}
+			costs[userId] = cost
+			userIds = append(userIds, userId)
+		}
+		return cost
+	}
+
+	for _, assignment := range assignments {
+		costOf(assignment.UserId).Assigned = true
+	}
+
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		cost := costOf(entry.UserId)
+		cost.spent += entry.DurationIn(time.Time{}, now, now)
+		cost.Timer = mergeTimerState(cost.Timer, entry.State())
+		cost.Running = cost.Timer == TimerRunning
+	}
+
+	// Данные пользователей
+	users, err := s.FindUsersByFilter(ctx, In("id", userIds...), nil, Pagination{})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+	for _, user := range users {
+		cost := costs[user.Id]
+		cost.Surname, cost.Name, cost.Patronymic = user.Surname, user.Name, user.Patronymic
+	}
+
+	result := make([]*TaskUserCost, 0, len(userIds))
+	for _, userId := range userIds {
+		cost := costs[userId]
+		cost.Seconds = int64(cost.spent / time.Second)
+		cost.Duration = formatDuration(cost.spent)
+		result = append(result, cost)
+	}
+
+	slices.SortFunc(result, func(a, b *TaskUserCost) int {
+		if c := cmp.Compare(b.spent, a.spent); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.UserId, b.UserId)
+	})
+
+	Logger.Debug("TimeTrackingService: TaskUsersCost cost calculated", slog.Int("taskId", int(task.Id)), slog.Int("users", len(result)))
+	return result, nil
+}
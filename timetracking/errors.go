@@ -17,6 +17,16 @@ type NotFoundError struct {
 	msg string
 }
 
+// Истекло время обработки запроса или запрос отменен
+type TimeoutError struct {
+	msg string
+}
+
+// Действие противоречит текущему состоянию, например уже идет другой таймер
+type ConflictError struct {
+	msg string
+}
+
 func (e InternalError) Error() string {
 	if e.msg == "" {
 		e.msg = "internal error"
@@ -44,3 +54,67 @@ func (e NotFoundError) Error() string {
 	}
 	return "timetracking: " + e.msg
 }
+
+func (e TimeoutError) Error() string {
+	if e.msg == "" {
+		e.msg = "timeout"
+	}
+	return "timetracking: " + e.msg
+}
+
+func (e ConflictError) Error() string {
+	if e.msg == "" {
+		e.msg = "conflict"
+	}
+	return "timetracking: " + e.msg
+}
+
+// Методы Is - сравнение ошибок по типу, чтобы работал errors.Is(err, &NotFoundError{})
+
+func (e InternalError) Is(target error) bool {
+	switch target.(type) {
+	case InternalError, *InternalError:
+		return true
+	}
+	return false
+}
+
+func (e StorageError) Is(target error) bool {
+	switch target.(type) {
+	case StorageError, *StorageError:
+		return true
+	}
+	return false
+}
+
+func (e InvalidError) Is(target error) bool {
+	switch target.(type) {
+	case InvalidError, *InvalidError:
+		return true
+	}
+	return false
+}
+
+func (e NotFoundError) Is(target error) bool {
+	switch target.(type) {
+	case NotFoundError, *NotFoundError:
+		return true
+	}
+	return false
+}
+
+func (e TimeoutError) Is(target error) bool {
+	switch target.(type) {
+	case TimeoutError, *TimeoutError:
+		return true
+	}
+	return false
+}
+
+func (e ConflictError) Is(target error) bool {
+	switch target.(type) {
+	case ConflictError, *ConflictError:
+		return true
+	}
+	return false
+}
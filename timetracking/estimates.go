@@ -0,0 +1,189 @@
+package timetracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// maxEstimate - ограничение оценки трудоемкости задачи
+const maxEstimate = 100000 * time.Hour
+
+// parseEstimate - оценка трудоемкости: неотрицательная длительность вида "8h" или "1h30m", с точностью до секунды
+func parseEstimate(s string) (time.Duration, error) {
+	estimate, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, &InvalidError{fmt.Sprintf("invalid estimate %q, expected duration like 8h or 1h30m", s)}
+	}
+	if estimate < 0 {
+		return 0, &InvalidError{"estimate is negative"}
+	}
+	if estimate > maxEstimate {
+		return 0, &InvalidError{fmt.Sprintf("estimate is longer than %s", maxEstimate)}
+	}
+	return estimate.Truncate(time.Second), nil
+}
+
+// estimateField - значение колонки оценки: пустая строка или 0 - оценка не задана
+func estimateField(s string) (any, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	estimate, err := parseEstimate(s)
+	if err != nil {
+		return nil, err
+	}
+	if estimate == 0 {
+		return nil, nil
+	}
+	return int64(estimate), nil
+}
+
+// Overdue - конец периода задачи прошел, а задача не завершена и не отменена
+func (t *Task) Overdue(now time.Time) bool {
+	return !t.Status.Closed() && !t.PeriodTo.IsZero() && t.PeriodTo.Before(now)
+}
+
+// Параметры отчета об оценках задач
+type EstimateParams struct {
+	Filter    Filter // фильтр задач
+	Sort      []Sort // сортировка задач
+	ProjectId int32  // только задачи проекта, 0 - все задачи
+	Overdue   *bool  // только просроченные (true) или непросроченные (false) задачи, nil - все
+	Overrun   *bool  // только превысившие оценку (true) или не превысившие (false) задачи, nil - все
+}
+
+// Оценка, фактическое и оставшееся время
+type EstimateTime struct {
+	EstimateSeconds   int64  `json:"estimateSeconds"`   // оценка в секундах, 0 - не задана
+	EstimateDuration  string `json:"estimateDuration"`  // оценка, например "8h 00m 00s"
+	ActualSeconds     int64  `json:"actualSeconds"`     // затраченное время в секундах, включая идущие таймеры
+	ActualDuration    string `json:"actualDuration"`    // затраченное время, например "1h 05m 09s"
+	RemainingSeconds  int64  `json:"remainingSeconds"`  // оставшаяся работа по оценке в секундах, не меньше 0
+	RemainingDuration string `json:"remainingDuration"` // оставшаяся работа по оценке
+
+	estimate  time.Duration
+	actual    time.Duration
+	remaining time.Duration
+}
+
+// add - добавить оценку и затраченное время задачи, задачи без оценки не добавляют оставшуюся работу
+func (t *EstimateTime) add(estimate, actual time.Duration) {
+	t.estimate += estimate
+	t.actual += actual
+	if estimate > 0 {
+		t.remaining += max(estimate-actual, 0)
+	}
+}
+
+// fill - заполнить поля ответа по накопленному времени
+func (t *EstimateTime) fill() {
+	t.EstimateSeconds = int64(t.estimate / time.Second)
+	t.EstimateDuration = formatDuration(t.estimate)
+	t.ActualSeconds = int64(t.actual / time.Second)
+	t.ActualDuration = formatDuration(t.actual)
+	t.RemainingSeconds = int64(t.remaining / time.Second)
+	t.RemainingDuration = formatDuration(t.remaining)
+}
+
+// Оценка задачи в сравнении с затраченным временем
+type TaskEstimate struct {
+	TaskId      int32      `json:"taskId"`                // идентификатор задачи
+	Title       string     `json:"title"`                 // название задачи
+	ProjectId   int32      `json:"projectId,omitempty"`   // проект, 0 - задача без проекта
+	PeriodTo    time.Time  `json:"periodTo"`              // конец периода задачи
+	Status      TaskStatus `json:"status"`                // статус задачи
+	CompletedAt *time.Time `json:"completedAt,omitempty"` // завершение задачи, nil - не завершена
+	Timer       TimerState `json:"timer"`                 // состояние отсчета времени
+	EstimateTime
+	Overdue bool `json:"overdue"` // конец периода прошел, а задача не завершена и не отменена
+	Overrun bool `json:"overrun"` // затраченное время превысило оценку
+}
+
+// Отчет об оценках задач
+type EstimateReport struct {
+	Tasks []*TaskEstimate `json:"tasks"` // задачи в порядке сортировки
+	EstimateTime
+	Overdue int `json:"overdue"` // количество просроченных задач
+	Overrun int `json:"overrun"` // количество задач, превысивших оценку
+}
+
+// EstimateReport - оценка, затраченное и оставшееся время задач, просроченные и превысившие оценку задачи
+func (s *TimeTrackingService) EstimateReport(ctx context.Context, params EstimateParams) (*EstimateReport, error) {
+	const op = "TimeTrackingService: EstimateReport"
+
+	Logger.Debug(op, slog.Any("params", params))
+
+	filter := params.Filter
+	if params.ProjectId != 0 {
+		filter = And(filter, Eq("project_id", params.ProjectId))
+	}
+
+	tasks, err := s.FindTasksByFilter(ctx, filter, params.Sort, Pagination{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Время идущих таймеров еще не вошло в общее время задач
+	now := time.Now()
+	running := map[int32]time.Duration{}
+	timers := map[int32]TimerState{}
+	if len(tasks) > 0 {
+		ids := make([]int32, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.Id
+		}
+
+		entries, err := s.FindTimeEntriesByFilter(ctx, And(In("task_id", ids...), IsNull("ended_at")), nil, Pagination{})
+		if err != nil {
+			return nil, processStorageError(op, err, false)
+		}
+
+		for _, entry := range entries {
+			running[entry.TaskId] += entry.DurationIn(entry.StartedAt, now, now)
+			timers[entry.TaskId] = mergeTimerState(timers[entry.TaskId], entry.State())
+		}
+	}
+
+	report := &EstimateReport{Tasks: []*TaskEstimate{}}
+	for _, task := range tasks {
+		estimate := &TaskEstimate{
+			TaskId:      task.Id,
+			Title:       task.Title,
+			ProjectId:   task.ProjectId,
+			PeriodTo:    task.PeriodTo,
+			Status:      task.Status,
+			CompletedAt: task.CompletedAt,
+			Timer:       mergeTimerState(timers[task.Id], TimerStopped),
+		}
+		actual := task.Cost + running[task.Id]
+		estimate.add(task.Estimate, actual)
+		estimate.fill()
+		estimate.Overdue = task.Overdue(now)
+		estimate.Overrun = task.Estimate > 0 && actual > task.Estimate
+
+		if params.Overdue != nil && *params.Overdue != estimate.Overdue {
+			continue
+		}
+		if params.Overrun != nil && *params.Overrun != estimate.Overrun {
+			continue
+		}
+
+		report.Tasks = append(report.Tasks, estimate)
+		report.add(task.Estimate, actual)
+		if estimate.Overdue {
+			report.Overdue++
+		}
+		if estimate.Overrun {
+			report.Overrun++
+		}
+	}
+	report.fill()
+
+	Logger.Debug("TimeTrackingService: EstimateReport report built", slog.Int("tasks", len(report.Tasks)), slog.Int("overdue", report.Overdue), slog.Int("overrun", report.Overrun))
+	return report, nil
+}
@@ -0,0 +1,385 @@
+package timetracking
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+
+	. "timetracking/storage"
+)
+
+// ExportFormat - формат ответа со списком или отчетом
+type ExportFormat string
+
+const (
+	FormatJSON ExportFormat = "json"
+	FormatCSV  ExportFormat = "csv"
+	FormatXLSX ExportFormat = "xlsx"
+	FormatHTML ExportFormat = "html"
+)
+
+// Типы содержимого форматов
+const (
+	contentTypeJSON = "application/json"
+	contentTypeCSV  = "text/csv"
+	contentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	contentTypeHTML = "text/html"
+)
+
+// exportBatchSize - количество записей, читаемых из хранилища за раз при выгрузке
+const exportBatchSize = 500
+
+// exportTimeLayout - формат даты и времени в выгрузках
+const exportTimeLayout = time.DateTime
+
+// formatContentTypes - типы содержимого форматов
+var formatContentTypes = map[string]ExportFormat{
+	contentTypeJSON: FormatJSON,
+	contentTypeCSV:  FormatCSV,
+	contentTypeXLSX: FormatXLSX,
+	contentTypeHTML: FormatHTML,
+}
+
+// negotiateFormat - формат ответа из supported: параметр format, иначе заголовок Accept, по умолчанию JSON
+func negotiateFormat(r *http.Request, supported ...ExportFormat) (ExportFormat, error) {
+	supported = append([]ExportFormat{FormatJSON}, supported...)
+
+	if format := r.URL.Query().Get("format"); format != "" {
+		if f := ExportFormat(strings.ToLower(format)); slices.Contains(supported, f) {
+			return f, nil
+		}
+		names := make([]string, len(supported))
+		for i, f := range supported {
+			names[i] = string(f)
+		}
+		return "", &InvalidError{fmt.Sprintf("unknown format %q, expected %s", format, strings.Join(names, ", "))}
+	}
+
+	// Первый поддерживаемый тип из Accept, веса не учитываются
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		if mediaType == "application/*" || mediaType == "*/*" {
+			return FormatJSON, nil
+		}
+		if f, ok := formatContentTypes[mediaType]; ok && slices.Contains(supported, f) {
+			return f, nil
+		}
+	}
+
+	return FormatJSON, nil
+}
+
+// Колонка выгрузки записей T
+type exportColumn[T any] struct {
+	Key    string       // название колонки в параметре columns
+	Header string       // заголовок колонки
+	Value  func(*T) any // значение колонки записи
+}
+
+// selectColumns - колонки из параметра columns: "ключ" или "ключ:Заголовок" через запятую,
+// порядок колонок - порядок в параметре. Пустой параметр - все колонки с заголовками по умолчанию
+func selectColumns[T any](columnsS string, all []exportColumn[T]) ([]exportColumn[T], error) {
+	if columnsS == "" {
+		return all, nil
+	}
+
+	var columns []exportColumn[T]
+	for _, item := range strings.Split(columnsS, ",") {
+		key, header, hasHeader := strings.Cut(item, ":")
+		key = strings.TrimSpace(key)
+
+		var column *exportColumn[T]
+		for i := range all {
+			if all[i].Key == key {
+				column = &all[i]
+				break
+			}
+		}
+		if column == nil {
+			return nil, &InvalidError{"unknown column " + key}
+		}
+
+		selected := *column
+		if hasHeader && strings.TrimSpace(header) != "" {
+			selected.Header = strings.TrimSpace(header)
+		}
+		columns = append(columns, selected)
+	}
+
+	return columns, nil
+}
+
+// tableWriter - построчная запись таблицы в ответ
+type tableWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// newTableWriter - запись таблицы в формате format, name - имя файла без расширения
+func newTableWriter(w http.ResponseWriter, format ExportFormat, name string) (tableWriter, error) {
+	switch format {
+	case FormatCSV:
+		w.Header().Set("Content-Type", contentTypeCSV+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		return newCSVTableWriter(w)
+
+	case FormatXLSX:
+		w.Header().Set("Content-Type", contentTypeXLSX)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, name))
+		return newXLSXTableWriter(w, name)
+	}
+
+	return nil, fmt.Errorf("timetracking: format %s is not a table", format)
+}
+
+// writeTable - заголовки колонок и строки записей
+func writeTable[T any](tw tableWriter, columns []exportColumn[T], items []*T, header bool) error {
+	if header {
+		headers := make([]any, len(columns))
+		for i, column := range columns {
+			headers[i] = column.Header
+		}
+		if err := tw.WriteRow(headers); err != nil {
+			return err
+		}
+	}
+
+	values := make([]any, len(columns))
+	for _, item := range items {
+		for i, column := range columns {
+			values[i] = exportValue(column.Value(item))
+		}
+		if err := tw.WriteRow(values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportValue - значение ячейки: время в формате exportTimeLayout, длительность как "1h 05m 09s",
+// суммы как "1500.00 RUB", nil, нулевое время и незаданная ставка - пустая ячейка
+func exportValue(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(exportTimeLayout)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return exportValue(*v)
+	case time.Duration:
+		return formatDuration(v)
+	case decimal.NullDecimal:
+		if !v.Valid {
+			return ""
+		}
+		return v.Decimal.StringFixed(rateDecimalPlaces)
+	case Money:
+		return v.String()
+	}
+	return v
+}
+
+// csvFlushRows - через сколько строк CSV отправляется клиенту
+const csvFlushRows = 100
+
+// csvTableWriter - таблица CSV, строки отправляются клиенту частями
+type csvTableWriter struct {
+	out  io.Writer
+	w    *csv.Writer
+	rows int
+}
+
+func newCSVTableWriter(w io.Writer) (*csvTableWriter, error) {
+	return &csvTableWriter{out: w, w: csv.NewWriter(w)}, nil
+}
+
+func (t *csvTableWriter) WriteRow(values []any) error {
+	// BOM, чтобы Excel открывал файл в UTF-8
+	if t.rows == 0 {
+		if _, err := t.out.Write([]byte("\ufeff")); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = fmt.Sprint(v)
+	}
+	if err := t.w.Write(record); err != nil {
+		return err
+	}
+
+	t.rows++
+	if t.rows%csvFlushRows == 0 {
+		t.w.Flush()
+	}
+	return t.w.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// xlsxTableWriter - таблица XLSX, строки пишутся потоком на лист, файл отправляется при закрытии
+type xlsxTableWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXTableWriter(w io.Writer, sheet string) (*xlsxTableWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxTableWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(values []any) error {
+	t.row++
+	cell, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+	return t.stream.SetRow(cell, values)
+}
+
+func (t *xlsxTableWriter) Close() error {
+	defer t.file.Close()
+
+	if err := t.stream.Flush(); err != nil {
+		return err
+	}
+	return t.file.Write(t.w)
+}
+
+// userColumns - колонки выгрузки пользователей
+var userColumns = []exportColumn[User]{
+	{Key: "surname", Header: "surname", Value: func(u *User) any { return u.Surname }},
+	{Key: "name", Header: "name", Value: func(u *User) any { return u.Name }},
+	{Key: "patronymic", Header: "patronymic", Value: func(u *User) any { return u.Patronymic }},
+	{Key: "address", Header: "address", Value: func(u *User) any { return u.Address }},
+	{Key: "workdayEnd", Header: "workdayEnd", Value: func(u *User) any { return u.WorkdayEnd }},
+	{Key: "timerPolicy", Header: "timerPolicy", Value: func(u *User) any { return u.TimerPolicy }},
+	{Key: "hourlyRate", Header: "hourlyRate", Value: func(u *User) any { return u.HourlyRate }},
+	{Key: "created", Header: "created", Value: func(u *User) any { return u.Created }},
+}
+
+// ExportUsers - выгрузка пользователей по фильтру и сортировке частями по exportBatchSize.
+// page.Limit > 0 ограничивает количество пользователей, page.Offset и page.After - начало выгрузки
+func (s *TimeTrackingService) ExportUsers(ctx context.Context, tw tableWriter, columns []exportColumn[User], filter Filter, sort []Sort, page Pagination) (int, error) {
+	const op = "TimeTrackingService: ExportUsers"
+
+	exported := 0
+	for {
+		batch := page
+		batch.Limit = exportBatchSize
+		if page.Limit > 0 {
+			batch.Limit = min(exportBatchSize, page.Limit-exported)
+		}
+		if batch.Limit <= 0 {
+			break
+		}
+
+		users, err := s.FindUsersByFilter(ctx, filter, sort, batch)
+		if err != nil {
+			return exported, err
+		}
+		// Заголовки пишутся после первого чтения, чтобы ошибку запроса можно было вернуть вместо таблицы
+		if err := writeTable(tw, columns, users, exported == 0); err != nil {
+			return exported, err
+		}
+		exported += len(users)
+
+		if len(users) < batch.Limit {
+			break
+		}
+
+		// Следующая часть - по курсору, если сортировка его допускает, иначе по смещению
+		last := users[len(users)-1]
+		if next := nextCursor(sort, batch, len(users), last.Created, last.Id); next != nil {
+			page.After, page.Offset = next, 0
+		} else {
+			page.Offset += len(users)
+		}
+	}
+
+	Logger.Debug(op+": users exported", slog.Int("count", exported))
+	return exported, nil
+}
+
+// Строка выгрузки отчета
+type reportLine struct {
+	Kind  string // row - задача, пользователь или проект за период, subtotal - итог за период, total - итог за отчет
+	Start time.Time
+	End   time.Time
+	Id    any // идентификатор задачи, пользователя или проекта, nil - итог
+	Name  string
+	ReportTime
+}
+
+// reportColumns - колонки выгрузки отчета
+var reportColumns = []exportColumn[reportLine]{
+	{Key: "kind", Header: "kind", Value: func(l *reportLine) any { return l.Kind }},
+	{Key: "start", Header: "start", Value: func(l *reportLine) any { return l.Start }},
+	{Key: "end", Header: "end", Value: func(l *reportLine) any { return l.End }},
+	{Key: "id", Header: "id", Value: func(l *reportLine) any { return l.Id }},
+	{Key: "name", Header: "name", Value: func(l *reportLine) any { return l.Name }},
+	{Key: "seconds", Header: "seconds", Value: func(l *reportLine) any { return l.Seconds }},
+	{Key: "duration", Header: "duration", Value: func(l *reportLine) any { return l.Duration }},
+	{Key: "billableSeconds", Header: "billableSeconds", Value: func(l *reportLine) any { return l.BillableSeconds }},
+	{Key: "amount", Header: "amount", Value: func(l *reportLine) any { return l.Amount }},
+}
+
+// reportLines - отчет построчно: по каждому периоду строки и итог, затем итоги за отчет и общий итог
+func reportLines(report *Report) []*reportLine {
+	var lines []*reportLine
+	for _, bucket := range report.Buckets {
+		for _, row := range bucket.Rows {
+			lines = append(lines, &reportLine{Kind: "row", Start: bucket.Start, End: bucket.End, Id: row.Id, Name: row.Name, ReportTime: row.ReportTime})
+		}
+		lines = append(lines, &reportLine{Kind: "subtotal", Start: bucket.Start, End: bucket.End, ReportTime: bucket.ReportTime})
+	}
+
+	for _, row := range report.Totals {
+		lines = append(lines, &reportLine{Kind: "total", Start: report.From, End: report.To, Id: row.Id, Name: row.Name, ReportTime: row.ReportTime})
+	}
+	lines = append(lines, &reportLine{Kind: "total", Start: report.From, End: report.To, ReportTime: report.ReportTime})
+
+	return lines
+}
+
+// writeReport - выгрузка отчета
+func writeReport(tw tableWriter, columns []exportColumn[reportLine], report *Report) error {
+	return writeTable(tw, columns, reportLines(report), true)
+}
@@ -1,6 +1,8 @@
 package timetracking
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,93 +15,1209 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/adaptor"
+
+	. "timetracking/storage"
 )
 
-// SetupHandlers - настройка обработчиков
-func (h *TimeTrackingService) SetupHandlers(group fiber.Router) {
-	group.Get("/info", adaptor.HTTPHandlerFunc(h.HandlerGetUser))
+// SetupHandlers - настройка обработчиков
+func (h *TimeTrackingService) SetupHandlers(group fiber.Router) {
+	group.Get("/info", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetUser)))
+
+	group.Get("/users", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetUsers)))
+
+	group.Get("/tasks", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTasks)))
+
+	group.Get("/task", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTask)))
+
+	group.Post("/tasks", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCreateTask)))
+
+	group.Put("/tasks", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerUpdateTask)))
+
+	group.Delete("/tasks", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerDeleteTask)))
+
+	group.Get("/projects", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetProjects)))
+
+	group.Get("/project", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetProject)))
+
+	group.Post("/projects", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCreateProject)))
+
+	group.Put("/projects", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerUpdateProject)))
+
+	group.Delete("/projects", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerDeleteProject)))
+
+	group.Get("/invoices", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetInvoices)))
+
+	group.Get("/invoice", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetInvoice)))
+
+	group.Post("/invoices", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCreateInvoice)))
+
+	group.Post("/task-status", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerChangeTaskStatus)))
+
+	group.Get("/task-status-history", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTaskStatusHistory)))
+
+	group.Get("/task-users", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTaskUsers)))
+
+	group.Post("/task-assignments", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerAssignTask)))
+
+	group.Delete("/task-assignments", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerUnassignTask)))
+
+	group.Get("/time-entries", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTimeEntries)))
+
+	group.Post("/time-entries", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCreateTimeEntry)))
+
+	group.Put("/time-entries", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerUpdateTimeEntry)))
+
+	group.Delete("/time-entries", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerDeleteTimeEntry)))
+
+	group.Get("/calculate-cost-by-user", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCalculateCostByUser)))
+
+	group.Get("/reports", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetReport)))
+
+	group.Get("/task-estimates", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetTaskEstimates)))
+
+	group.Post("/begin-task-for-user", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerBeginTaskForUser)))
+
+	group.Post("/end-task-for-user", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerEndTaskForUser)))
+
+	group.Post("/pause-task-for-user", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerPauseTaskForUser)))
+
+	group.Post("/resume-task-for-user", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerResumeTaskForUser)))
+
+	group.Get("/current-timer", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerGetCurrentTimer)))
+
+	group.Delete("/users", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerDeleteUser)))
+
+	group.Put("/users", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerUpdateUser)))
+
+	group.Post("/users", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerCreateUser)))
+
+	group.Get("/pool-stats", adaptor.HTTPHandlerFunc(h.withTimeout(h.HandlerPoolStats)))
+}
+
+// withTimeout - ограничение времени обработки запроса, контекст с дедлайном
+// передается в сервис и хранилище
+func (h *TimeTrackingService) withTimeout(handler http.HandlerFunc) http.HandlerFunc {
+	if h.requestTimeout <= 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+		defer cancel()
+
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// HandlerGetUser - получение данных пользователя
+// @Summary Get user data
+// @Description Get user data by passport series and number
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param   pasportSeries    query    string  true  "Passport series"
+// @Param   pasportNumber    query    string  true  "Passport number"
+// @Success 200 {object} User
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /info [get]
+func (h *TimeTrackingService) HandlerGetUser(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetUser"
+
+	slog.Info(op)
+
+	pasportSeries := r.URL.Query().Get("pasportSeries")
+	pasportNumber := r.URL.Query().Get("pasportNumber")
+
+	user, err := h.FindUserByPassport(r.Context(), pasportSeries, pasportNumber)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(user)
+	sendResponseOrError("HandlerGetUser", err, w, body, slog.Any("user", user))
+}
+
+// HandlerGetUsers - получение данных пользователей по фильтру и пагинации
+// @Summary Get users data by filter and pagination
+// @Description Get users data by filter and pagination, as JSON, CSV or XLSX. Without limit CSV and XLSX contain all users by the filter
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   filter    query    string  false  "Filter: field=value or field__op=value joined by &&, op: eq, neq, gt, gte, lt, lte, in, like, isnull"
+// @Param   sort      query    string  false  "Sort: comma-separated fields, '-' for descending (id, surname, name, patronymic, address, created)"
+// @Param   limit     query    int     false  "Limit"
+// @Param   offset    query    int     false  "Offset"
+// @Param   after     query    string  false  "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset"
+// @Param   format    query    string  false  "Response format: json (default), csv or xlsx, also chosen by the Accept header"
+// @Param   columns   query    string  false  "CSV/XLSX columns in order: key or key:Header, comma-separated (surname, name, patronymic, address, workdayEnd, timerPolicy, created)"
+// @Success 200 {object} UsersPage
+// @Header  200 {string} Link "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /users [get]
+func (h *TimeTrackingService) HandlerGetUsers(w http.ResponseWriter, r *http.Request) {
+	slog.Info("TimeTrackingService: HandlerGetUsers")
+
+	filterS := r.URL.Query().Get("filter")
+	sortS := r.URL.Query().Get("sort")
+
+	filter, err := parseFilter(filterS)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	sort, err := parseSort(sortS, userSortFields)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	slog.Debug("TimeTrackingService: HandlerGetUsers", slog.String("filterString", filterS), slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	format, err := negotiateFormat(r, FormatCSV, FormatXLSX)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	// Выгрузка в CSV или XLSX
+	if format != FormatJSON {
+		columns, err := selectColumns(r.URL.Query().Get("columns"), userColumns)
+		if err != nil {
+			sendResponseOrError("HandlerGetUsers", err, w, nil)
+			return
+		}
+
+		sendTable("HandlerGetUsers", w, format, "users", func(tw tableWriter) error {
+			_, err := h.ExportUsers(r.Context(), tw, columns, filter, sort, page)
+			return err
+		})
+		return
+	}
+
+	users, err := h.FindUsersByFilter(r.Context(), filter, sort, page)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	total, err := h.CountUsersByFilter(r.Context(), filter)
+	if err != nil {
+		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		return
+	}
+
+	var next *Cursor
+	if len(users) > 0 {
+		last := users[len(users)-1]
+		next = nextCursor(sort, page, len(users), last.Created, last.Id)
+	}
+
+	meta := newPage(r, total, page, next)
+	meta.setLinkHeader(w)
+
+	body, err := json.Marshal(UsersPage{Page: meta, Users: users})
+	sendResponseOrError("HandlerGetUsers", err, w, body, slog.String("users", fmt.Sprintf("%+v", users)))
+}
+
+// HandlerGetTasks - получение задач по фильтру, сортировке и пагинации
+// @Summary Get tasks by filter and pagination
+// @Description Get tasks by filter, sort and pagination
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   filter    query    string  false  "Filter: field=value or field__op=value joined by &&, op: eq, neq, gt, gte, lt, lte, in, like, isnull"
+// @Param   sort      query    string  false  "Sort: comma-separated fields, '-' for descending (id, title, project_id, period_from, period_to, cost, estimate, status, completed_at, created)"
+// @Param   limit     query    int     false  "Limit"
+// @Param   offset    query    int     false  "Offset"
+// @Param   after     query    string  false  "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset"
+// @Success 200 {object} TasksPage
+// @Header  200 {string} Link "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /tasks [get]
+func (h *TimeTrackingService) HandlerGetTasks(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTasks"
+
+	slog.Info(op)
+
+	filterS := r.URL.Query().Get("filter")
+	sortS := r.URL.Query().Get("sort")
+
+	filter, err := parseFilter(filterS)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	sort, err := parseSort(sortS, taskSortFields)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	slog.Debug(op, slog.String("filterString", filterS), slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	tasks, err := h.FindTasksByFilter(r.Context(), filter, sort, page)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.FillTaskTimers(r.Context(), tasks)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	total, err := h.CountTasksByFilter(r.Context(), filter)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var next *Cursor
+	if len(tasks) > 0 {
+		last := tasks[len(tasks)-1]
+		next = nextCursor(sort, page, len(tasks), last.Created, last.Id)
+	}
+
+	meta := newPage(r, total, page, next)
+	meta.setLinkHeader(w)
+
+	body, err := json.Marshal(TasksPage{Page: meta, Tasks: tasks})
+	sendResponseOrError(op, err, w, body, slog.Int("count", len(tasks)))
+}
+
+// HandlerGetTask - получение задачи по идентификатору
+// @Summary Get task
+// @Description Get task by id
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Task ID"
+// @Success 200 {object} Task
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task [get]
+func (h *TimeTrackingService) HandlerGetTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTask"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	task, err := h.FindTaskById(r.Context(), id)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.FillTaskTimers(r.Context(), []*Task{task})
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(task)
+	sendResponseOrError(op, err, w, body, slog.Int("taskId", int(id)))
+}
+
+// HandlerCreateTask - создание задачи
+// @Summary Create task
+// @Description Create task, title is required (up to 100 characters), description up to 500 characters, projectId - active project of the task, hourlyRate - string like "1500.00", billable by default
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   body     body    TaskData   true        "Task data"
+// @Success 200 {int32} int32 0
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /tasks [post]
+func (h *TimeTrackingService) HandlerCreateTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerCreateTask"
+
+	slog.Info(op)
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data TaskData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	newId, err := h.CreateTask(r.Context(), data)
+	sendResponseOrError(op, err, w, []byte(fmt.Sprintf(`{"id": %d}`, newId)))
+}
+
+// HandlerUpdateTask - изменение задачи
+// @Summary Update task
+// @Description Update given fields of the task, title up to 100 characters, description up to 500 characters, projectId 0 - remove the task from its project, empty hourlyRate removes the rate, empty estimate removes the estimate. The status is changed by POST /task-status
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id       query   int        true  "Task ID"
+// @Param   body     body    TaskData   true  "Task data"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /tasks [put]
+func (h *TimeTrackingService) HandlerUpdateTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerUpdateTask"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data TaskData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	err = h.UpdateTask(r.Context(), id, data)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerDeleteTask - удаление задачи
+// @Summary Delete task
+// @Description Delete task with its time entries, a started task or a task with invoiced time can't be deleted
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Task ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача начата или интервал работы вошел в счет"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /tasks [delete]
+func (h *TimeTrackingService) HandlerDeleteTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerDeleteTask"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.DeleteTask(r.Context(), id)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerGetProjects - получение проектов по фильтру, сортировке и пагинации
+// @Summary Get projects by filter and pagination
+// @Description Get projects by filter, sort and pagination with task count and time spent on their tasks
+// @Tags Project
+// @Accept  json
+// @Produce  json
+// @Param   filter    query    string  false  "Filter: field=value or field__op=value joined by &&, op: eq, neq, gt, gte, lt, lte, in, like, isnull"
+// @Param   sort      query    string  false  "Sort: comma-separated fields, '-' for descending (id, name, code, client, period_from, period_to, created)"
+// @Param   limit     query    int     false  "Limit"
+// @Param   offset    query    int     false  "Offset"
+// @Param   after     query    string  false  "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset"
+// @Param   active    query    bool    false  "Only active (true) or inactive (false) projects"
+// @Success 200 {object} ProjectsPage
+// @Header  200 {string} Link "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /projects [get]
+func (h *TimeTrackingService) HandlerGetProjects(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetProjects"
+
+	slog.Info(op)
+
+	filterS := r.URL.Query().Get("filter")
+	sortS := r.URL.Query().Get("sort")
+
+	filter, err := parseFilter(filterS)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	sort, err := parseSort(sortS, projectSortFields)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	if activeS := r.URL.Query().Get("active"); activeS != "" {
+		active, err := strconv.ParseBool(activeS)
+		if err != nil {
+			sendResponseOrError(op, &InvalidError{"invalid active"}, w, nil)
+			return
+		}
+		filter = And(filter, Eq("active", active))
+	}
+
+	slog.Debug(op, slog.String("filterString", filterS), slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	projects, err := h.FindProjectsByFilter(r.Context(), filter, sort, page)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.FillProjectCosts(r.Context(), projects)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	total, err := h.CountProjectsByFilter(r.Context(), filter)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var next *Cursor
+	if len(projects) > 0 {
+		last := projects[len(projects)-1]
+		next = nextCursor(sort, page, len(projects), last.Created, last.Id)
+	}
+
+	meta := newPage(r, total, page, next)
+	meta.setLinkHeader(w)
+
+	body, err := json.Marshal(ProjectsPage{Page: meta, Projects: projects})
+	sendResponseOrError(op, err, w, body, slog.Int("count", len(projects)))
+}
+
+// HandlerGetProject - получение проекта по идентификатору
+// @Summary Get project
+// @Description Get project by id with task count and time spent on its tasks
+// @Tags Project
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Project ID"
+// @Success 200 {object} Project
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /project [get]
+func (h *TimeTrackingService) HandlerGetProject(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetProject"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	project, err := h.FindProjectById(r.Context(), id)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.FillProjectCosts(r.Context(), []*Project{project})
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(project)
+	sendResponseOrError(op, err, w, body, slog.Int("projectId", int(id)))
+}
+
+// HandlerCreateProject - создание проекта
+// @Summary Create project
+// @Description Create project, name (up to 100 characters) and unique code (up to 20 characters, no spaces) are required, the project is active and billable by default, currency - ISO 4217 code of its rates (default currency if empty)
+// @Tags Project
+// @Accept  json
+// @Produce  json
+// @Param   body     body    ProjectData   true        "Project data"
+// @Success 200 {int32} int32 0
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Код проекта занят"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /projects [post]
+func (h *TimeTrackingService) HandlerCreateProject(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerCreateProject"
+
+	slog.Info(op)
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data ProjectData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	newId, err := h.CreateProject(r.Context(), data)
+	sendResponseOrError(op, err, w, []byte(fmt.Sprintf(`{"id": %d}`, newId)))
+}
+
+// HandlerUpdateProject - изменение проекта
+// @Summary Update project
+// @Description Update given fields of the project, tasks can't be added to an inactive project
+// @Tags Project
+// @Accept  json
+// @Produce  json
+// @Param   id       query   int           true  "Project ID"
+// @Param   body     body    ProjectData   true  "Project data"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Код проекта занят"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /projects [put]
+func (h *TimeTrackingService) HandlerUpdateProject(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerUpdateProject"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data ProjectData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	err = h.UpdateProject(r.Context(), id, data)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerDeleteProject - удаление проекта
+// @Summary Delete project
+// @Description Delete project, a project with tasks can't be deleted
+// @Tags Project
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Project ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /projects [delete]
+func (h *TimeTrackingService) HandlerDeleteProject(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerDeleteProject"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.DeleteProject(r.Context(), id)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerGetInvoices - получение счетов по фильтру, сортировке и пагинации
+// @Summary Get invoices by filter and pagination
+// @Description Get invoices without lines by filter, sort and pagination
+// @Tags Invoice
+// @Accept  json
+// @Produce  json
+// @Param   filter    query    string  false  "Filter: field=value or field__op=value joined by &&, op: eq, neq, gt, gte, lt, lte, in, like, isnull"
+// @Param   sort      query    string  false  "Sort: comma-separated fields, '-' for descending (id, number, client, project_id, period_from, period_to, created)"
+// @Param   limit     query    int     false  "Limit"
+// @Param   offset    query    int     false  "Offset"
+// @Param   after     query    string  false  "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset"
+// @Success 200 {object} InvoicesPage
+// @Header  200 {string} Link "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /invoices [get]
+func (h *TimeTrackingService) HandlerGetInvoices(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetInvoices"
+
+	slog.Info(op)
+
+	filterS := r.URL.Query().Get("filter")
+	sortS := r.URL.Query().Get("sort")
+
+	filter, err := parseFilter(filterS)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	sort, err := parseSort(sortS, invoiceSortFields)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	slog.Debug(op, slog.String("filterString", filterS), slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	invoices, err := h.FindInvoicesByFilter(r.Context(), filter, sort, page)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	total, err := h.CountInvoicesByFilter(r.Context(), filter)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var next *Cursor
+	if len(invoices) > 0 {
+		last := invoices[len(invoices)-1]
+		next = nextCursor(sort, page, len(invoices), last.Created, last.Id)
+	}
+
+	meta := newPage(r, total, page, next)
+	meta.setLinkHeader(w)
+
+	body, err := json.Marshal(InvoicesPage{Page: meta, Invoices: invoices})
+	sendResponseOrError(op, err, w, body, slog.Int("count", len(invoices)))
+}
+
+// HandlerGetInvoice - получение счета по идентификатору
+// @Summary Get invoice
+// @Description Get invoice with its lines by id, as JSON or as an HTML page for printing (format=html or Accept: text/html).
+// @Description PDF is not supported: format=pdf is rejected, print the HTML page to PDF in the browser
+// @Tags Invoice
+// @Accept  json
+// @Produce  json
+// @Produce  text/html
+// @Param   id        query    int     true   "Invoice ID"
+// @Param   format    query    string  false  "Response format: json (default) or html, pdf is not supported"
+// @Success 200 {object} Invoice
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /invoice [get]
+func (h *TimeTrackingService) HandlerGetInvoice(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetInvoice"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	if strings.EqualFold(r.URL.Query().Get("format"), "pdf") {
+		sendResponseOrError(op, errInvoicePDF, w, nil)
+		return
+	}
+	format, err := negotiateFormat(r, FormatHTML)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	invoice, err := h.FindInvoiceById(r.Context(), id)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	if format == FormatHTML {
+		var body bytes.Buffer
+		err = writeInvoiceHTML(&body, invoice)
+		if err == nil {
+			w.Header().Set("Content-Type", contentTypeHTML+"; charset=utf-8")
+		}
+		sendResponseOrError(op, err, w, body.Bytes(), slog.Int("invoiceId", int(id)))
+		return
+	}
+
+	body, err := json.Marshal(invoice)
+	sendResponseOrError(op, err, w, body, slog.Int("invoiceId", int(id)))
+}
+
+// HandlerCreateInvoice - выставление счета
+// @Summary Create invoice
+// @Description Create a numbered invoice from unbilled billable time of the client's projects (or one project) started in the period.
+// @Description Only ended time entries are invoiced, every billable entry must have an hourly rate, all time must be in one currency.
+// @Description Lines are per task or per user and rate. Invoiced time entries can't be edited or deleted
+// @Tags Invoice
+// @Accept  json
+// @Produce  json
+// @Param   body     body    InvoiceParams   true        "Client or project, period and grouping of lines"
+// @Success 200 {int32} int32 0
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Номер счета занят параллельно созданным счетом, нужно повторить запрос"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /invoices [post]
+func (h *TimeTrackingService) HandlerCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerCreateInvoice"
+
+	slog.Info(op)
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var params InvoiceParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	newId, err := h.CreateInvoice(r.Context(), params)
+	sendResponseOrError(op, err, w, []byte(fmt.Sprintf(`{"id": %d}`, newId)))
+}
+
+// HandlerChangeTaskStatus - смена статуса задачи
+// @Summary Change task status
+// @Description Move the task to another status: todo -> in_progress, done, cancelled; in_progress -> paused, done, cancelled;
+// @Description paused -> in_progress, done, cancelled; done -> in_progress; cancelled -> todo.
+// @Description A task with started time entries can't be paused, done or cancelled. Time isn't tracked on done and cancelled tasks
+// @Description Beginning a task moves it to in_progress, stopping its last timer moves it from in_progress to paused
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int             true  "Task ID"
+// @Param   body  body     TaskStatusData  true  "New status and note"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Переход из текущего статуса недопустим или задача начата"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-status [post]
+func (h *TimeTrackingService) HandlerChangeTaskStatus(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerChangeTaskStatus"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data TaskStatusData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	err = h.ChangeTaskStatus(r.Context(), id, data)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerGetTaskStatusHistory - история статусов задачи
+// @Summary Get task status history
+// @Description Status changes of the task in order, the first one is the creation of the task
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Task ID"
+// @Success 200 {object} map[string][]TaskStatusChange "История статусов (history)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-status-history [get]
+func (h *TimeTrackingService) HandlerGetTaskStatusHistory(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTaskStatusHistory"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	history, err := h.TaskStatusHistory(r.Context(), id)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"history": history,
+	})
+	sendResponseOrError(op, err, w, body, slog.Int("changes", len(history)))
+}
+
+// HandlerGetTaskUsers - время работы над задачей по пользователям
+// @Summary Get task cost by users
+// @Description Get assigned users and users who worked on the task with their time, sorted by time spent
+// @Tags Task
+// @Accept  json
+// @Produce  json
+// @Param   id    query    int  true  "Task ID"
+// @Success 200 {object} map[string][]TaskUserCost "Время по пользователям (users)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-users [get]
+func (h *TimeTrackingService) HandlerGetTaskUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTaskUsers"
+
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	users, err := h.TaskUsersCost(r.Context(), id)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"users": users,
+	})
+	sendResponseOrError(op, err, w, body, slog.Int("users", len(users)))
+}
+
+// HandlerAssignTask - назначить пользователя на задачу
+// @Summary Assign user to task
+// @Description Assign user to task, several users can work on one task
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param pasportNumber body string true "Passport number"
+// @Param taskId        body string true "Task ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-assignments [post]
+func (h *TimeTrackingService) HandlerAssignTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerAssignTask"
+
+	slog.Info(op)
+
+	seriesNumber, taskId, err := parseUserTask(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.AssignTask(r.Context(), seriesNumber[0], seriesNumber[1], taskId)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerUnassignTask - снять пользователя с задачи
+// @Summary Unassign user from task
+// @Description Unassign user from task, user's time entries are kept
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param pasportNumber body string true "Passport number"
+// @Param taskId        body string true "Task ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Пользователь начал задачу"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-assignments [delete]
+func (h *TimeTrackingService) HandlerUnassignTask(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerUnassignTask"
+
+	slog.Info(op)
+
+	seriesNumber, taskId, err := parseUserTask(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.UnassignTask(r.Context(), seriesNumber[0], seriesNumber[1], taskId)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerGetTimeEntries - получение интервалов работы по фильтру, сортировке и пагинации
+// @Summary Get time entries by filter and pagination
+// @Description Get time entries (intervals of work of users on tasks) by filter, sort and pagination
+// @Tags Time Tracking
+// @Accept  json
+// @Produce  json
+// @Param   filter    query    string  false  "Filter: field=value or field__op=value joined by &&, op: eq, neq, gt, gte, lt, lte, in, like, isnull"
+// @Param   sort      query    string  false  "Sort: comma-separated fields, '-' for descending (id, task_id, user_id, started_at, ended_at, created)"
+// @Param   limit     query    int     false  "Limit"
+// @Param   offset    query    int     false  "Offset"
+// @Param   after     query    string  false  "Cursor from nextCursor: the page after it, sorted by created (sort: created or -created), can't be used with offset"
+// @Param   autoStopped query  bool    false  "Only entries stopped automatically (true) or by user (false)"
+// @Success 200 {object} TimeEntriesPage
+// @Header  200 {string} Link "Ссылки на следующую и предыдущую страницы (RFC 8288)"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /time-entries [get]
+func (h *TimeTrackingService) HandlerGetTimeEntries(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTimeEntries"
+
+	slog.Info(op)
+
+	filterS := r.URL.Query().Get("filter")
+	sortS := r.URL.Query().Get("sort")
+
+	filter, err := parseFilter(filterS)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	// Интервалы, остановленные автоматически, для проверки
+	if autoStoppedS := r.URL.Query().Get("autoStopped"); autoStoppedS != "" {
+		autoStopped, err := strconv.ParseBool(autoStoppedS)
+		if err != nil {
+			sendResponseOrError(op, &InvalidError{"invalid autoStopped"}, w, nil)
+			return
+		}
+		filter = And(filter, Eq("auto_stopped", autoStopped))
+	}
+
+	sort, err := parseSort(sortS, timeEntrySortFields)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
 
-	group.Get("/users", adaptor.HTTPHandlerFunc(h.HandlerGetUsers))
+	page, err := parsePagination(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
 
-	group.Get("/calculate-cost-by-user", adaptor.HTTPHandlerFunc(h.HandlerCalculateCostByUser))
+	slog.Debug(op, slog.String("filterString", filterS), slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
 
-	group.Post("/begin-task-for-user", adaptor.HTTPHandlerFunc(h.HandlerBeginTaskForUser))
+	entries, err := h.FindTimeEntriesByFilter(r.Context(), filter, sort, page)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
 
-	group.Post("/end-task-for-user", adaptor.HTTPHandlerFunc(h.HandlerEndTaskForUser))
+	total, err := h.CountTimeEntriesByFilter(r.Context(), filter)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
 
-	group.Delete("/users", adaptor.HTTPHandlerFunc(h.HandlerDeleteUser))
+	var next *Cursor
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		next = nextCursor(sort, page, len(entries), last.Created, last.Id)
+	}
 
-	group.Put("/users", adaptor.HTTPHandlerFunc(h.HandlerUpdateUser))
+	meta := newPage(r, total, page, next)
+	meta.setLinkHeader(w)
 
-	group.Post("/users", adaptor.HTTPHandlerFunc(h.HandlerCreateUser))
+	body, err := json.Marshal(TimeEntriesPage{Page: meta, TimeEntries: entries})
+	sendResponseOrError(op, err, w, body, slog.Int("count", len(entries)))
 }
 
-// HandlerGetUser - получение данных пользователя
-// @Summary Get user data
-// @Description Get user data by passport series and number
-// @Tags User
+// HandlerCreateTimeEntry - ручное добавление интервала работы
+// @Summary Create time entry
+// @Description Add an ended interval of work of the user on the task afterwards, it must not overlap other intervals of the user. Done and cancelled tasks don't accept time
+// @Tags Time Tracking
 // @Accept  json
 // @Produce  json
-// @Param   pasportSeries    query    string  true  "Passport series"
-// @Param   pasportNumber    query    string  true  "Passport number"
-// @Success 200 {object} User
+// @Param pasportNumber body string true "Passport number"
+// @Param taskId        body int    true "Task ID"
+// @Param startedAt     body string true "Start of work, RFC 3339"
+// @Param endedAt       body string true "End of work, RFC 3339, not in the future"
+// @Param note          body string false "Note for the time entry"
+// @Success 200 {int32} int32 0
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача завершена или отменена"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
-// @Router /info [get]
-func (h *TimeTrackingService) HandlerGetUser(w http.ResponseWriter, r *http.Request) {
-	const op = "TimeTrackingService: HandlerGetUser"
+// @Router /time-entries [post]
+func (h *TimeTrackingService) HandlerCreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerCreateTimeEntry"
 
 	slog.Info(op)
 
-	pasportSeries := r.URL.Query().Get("pasportSeries")
-	pasportNumber := r.URL.Query().Get("pasportNumber")
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data struct {
+		PasportSeriesNumber string `json:"pasportNumber"`
+		TimeEntryData
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	seriesNumber := strings.Split(data.PasportSeriesNumber, " ")
+	if len(seriesNumber) != 2 || seriesNumber[0] == "" || seriesNumber[1] == "" {
+		sendResponseOrError(op, &InvalidError{"invalid passport"}, w, nil)
+		return
+	}
+
+	newId, err := h.CreateTimeEntry(r.Context(), seriesNumber[0], seriesNumber[1], data.TimeEntryData)
+	sendResponseOrError(op, err, w, []byte(fmt.Sprintf(`{"id": %d}`, newId)))
+}
+
+// HandlerUpdateTimeEntry - ручное изменение интервала работы
+// @Summary Update time entry
+// @Description Update given fields of an ended time entry, it must not overlap other intervals of the user and must contain its pauses. Invoiced entries can't be edited, entries of done or cancelled tasks can't be edited or moved to such tasks
+// @Tags Time Tracking
+// @Accept  json
+// @Produce  json
+// @Param   id       query   int            true  "Time entry ID"
+// @Param   body     body    TimeEntryData  true  "Time entry data"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Интервал работы не завершен, вошел в счет или задача завершена"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /time-entries [put]
+func (h *TimeTrackingService) HandlerUpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerUpdateTimeEntry"
+
+	slog.Info(op)
 
-	user, err := h.FindUserByPassport(pasportSeries, pasportNumber)
+	id, err := parseId(r.URL.Query().Get("id"))
 	if err != nil {
 		sendResponseOrError(op, err, w, nil)
+		return
 	}
 
-	body, err := json.Marshal(user)
-	sendResponseOrError("HandlerGetUser", err, w, body, slog.Any("user", user))
+	body, err := io.ReadAll(r.Body)
+	slog.Debug(op, slog.String("body", string(body)))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	var data TimeEntryData
+	if err := json.Unmarshal(body, &data); err != nil {
+		sendResponseOrError(op, &InvalidError{err.Error()}, w, nil)
+		return
+	}
+
+	err = h.UpdateTimeEntry(r.Context(), id, data)
+	sendResponseOrError(op, err, w, nil)
 }
 
-// HandlerGetUsers - получение данных пользователей по фильтру и пагинации
-// @Summary Get users data by filter and pagination
-// @Description Get users data by filter and pagination
-// @Tags User
+// HandlerDeleteTimeEntry - удаление интервала работы
+// @Summary Delete time entry
+// @Description Delete an ended time entry, its time is subtracted from the task. Invoiced entries and entries of done or cancelled tasks can't be deleted
+// @Tags Time Tracking
 // @Accept  json
 // @Produce  json
-// @Param   filter    query    string  false  "Filter"
-// @Param   limit     query    int     false  "Limit"
-// @Param   offset    query    int     false  "Offset"
-// @Success 200 {array} User
+// @Param   id    query    int  true  "Time entry ID"
+// @Success 200 {string} string "OK"
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Интервал работы не завершен, вошел в счет или задача завершена"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
-// @Router /users [get]
-func (h *TimeTrackingService) HandlerGetUsers(w http.ResponseWriter, r *http.Request) {
-	slog.Info("TimeTrackingService: HandlerGetUsers")
+// @Router /time-entries [delete]
+func (h *TimeTrackingService) HandlerDeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerDeleteTimeEntry"
 
-	filterS := r.URL.Query().Get("filter")
-	limitS := r.URL.Query().Get("limit")
-	offsetS := r.URL.Query().Get("offset")
+	slog.Info(op)
+
+	id, err := parseId(r.URL.Query().Get("id"))
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.DeleteTimeEntry(r.Context(), id)
+	sendResponseOrError(op, err, w, nil)
+}
 
-	filter := parseFilter(filterS)
+// HandlerGetCurrentTimer - текущий таймер пользователя
+// @Summary Get current timer of user
+// @Description Get the running timer of the user (null if the user is not working now) and the paused timers
+// @Tags Time Tracking
+// @Accept  json
+// @Produce  json
+// @Param   pasportSeries    query    string  true  "Passport series"
+// @Param   pasportNumber    query    string  true  "Passport number"
+// @Success 200 {object} CurrentTimers
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /current-timer [get]
+func (h *TimeTrackingService) HandlerGetCurrentTimer(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetCurrentTimer"
 
-	limit, _ := strconv.Atoi(limitS)
-	offset, _ := strconv.Atoi(offsetS)
+	slog.Info(op)
 
-	slog.Debug("TimeTrackingService: HandlerGetUsers", slog.String("filterString", filterS), slog.Any("filter", filter), slog.Int("limit", limit), slog.Int("offset", offset))
+	pasportSeries := r.URL.Query().Get("pasportSeries")
+	pasportNumber := r.URL.Query().Get("pasportNumber")
 
-	users, err := h.FindUsersByFilter(filter, limit, offset)
+	timers, err := h.CurrentTimersForUser(r.Context(), pasportSeries, pasportNumber)
 	if err != nil {
-		sendResponseOrError("HandlerGetUsers", err, w, nil)
+		sendResponseOrError(op, err, w, nil)
 		return
 	}
 
-	body, err := json.Marshal(map[string]any{
-		"users": users,
-	})
-	sendResponseOrError("HandlerGetUsers", err, w, body, slog.String("users", fmt.Sprintf("%+t", users)))
+	body, err := json.Marshal(timers)
+	sendResponseOrError(op, err, w, body)
 }
 
 // @Summary Затраты времени на задачи
@@ -111,7 +1229,7 @@ func (h *TimeTrackingService) HandlerGetUsers(w http.ResponseWriter, r *http.Req
 // @Param pasportNumber query string true "Номер паспорта"
 // @Param periodFrom    query string true "Начало периода (в формате ISO 8601)"
 // @Param periodTo      query string true "Окончание периода (в формате ISO 8601)"
-// @Success 200 {object} map[string]any "Список счетов (costs)"
+// @Success 200 {object} map[string][]TaskCost "Время по задачам (costs), по убыванию"
 // @Failure 400 {string} error "Неверные параметры запроса"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
 // @Router /calculate-cost-by-user [get]
@@ -145,7 +1263,7 @@ func (h *TimeTrackingService) HandlerCalculateCostByUser(w http.ResponseWriter,
 
 	slog.Debug("TimeTrackingService: HandlerCalculateCostByUser", slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Any("periodFrom", periodFrom), slog.Any("periodTo", periodTo))
 
-	cost, err := h.CalculateCostByUser(pasportSeries, pasportNumber, periodFrom, periodTo)
+	cost, err := h.CalculateCostByUser(r.Context(), pasportSeries, pasportNumber, periodFrom, periodTo)
 	if err != nil {
 		sendResponseOrError("HandlerCalculateCostByUser", err, w, nil)
 		return
@@ -154,19 +1272,147 @@ func (h *TimeTrackingService) HandlerCalculateCostByUser(w http.ResponseWriter,
 	body, err := json.Marshal(map[string]any{
 		"costs": cost,
 	})
-	sendResponseOrError("HandlerCalculateCostByUser", err, w, body, slog.Any("costs", cost))
+	sendResponseOrError("HandlerCalculateCostByUser", err, w, body, slog.Int("tasks", len(cost)))
+}
+
+// HandlerGetReport - отчет о затраченном времени
+// @Summary Time report
+// @Description Time spent by days, weeks or months grouped by tasks, users or projects, with totals. Periods without time are included.
+// @Description Billable time is priced by the task rate, else the project rate, else the user rate; amounts are per currency.
+// @Description CSV and XLSX have a line per task, user or project in a period (kind=row), per period (subtotal) and per report (total)
+// @Tags Time Tracking
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param periodFrom    query string true  "First day of the report (YYYY-MM-DD)"
+// @Param periodTo      query string true  "Last day of the report, inclusive (YYYY-MM-DD)"
+// @Param period        query string false "Period: day (default), week (from Monday) or month"
+// @Param groupBy       query string false "Grouping: task (default), user or project (tasks without project - id 0)"
+// @Param pasportSeries query string false "Only time of the user: passport series"
+// @Param pasportNumber query string false "Only time of the user: passport number"
+// @Param taskId        query int    false "Only time of the task"
+// @Param projectId     query int    false "Only time of the tasks of the project"
+// @Param tz            query string false "Time zone of day boundaries (IANA name, e.g. Europe/Moscow), default - time zone of the -workday-tz flag"
+// @Param format        query string false "Response format: json (default), csv or xlsx, also chosen by the Accept header"
+// @Param columns       query string false "CSV/XLSX columns in order: key or key:Header, comma-separated (kind, start, end, id, name, seconds, duration)"
+// @Success 200 {object} Report
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /reports [get]
+func (h *TimeTrackingService) HandlerGetReport(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetReport"
+
+	slog.Info(op)
+
+	params, err := parseReportParams(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	format, err := negotiateFormat(r, FormatCSV, FormatXLSX)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	columns, err := selectColumns(r.URL.Query().Get("columns"), reportColumns)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	report, err := h.Report(r.Context(), params)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	// Выгрузка в CSV или XLSX
+	if format != FormatJSON {
+		sendTable(op, w, format, "report", func(tw tableWriter) error {
+			return writeReport(tw, columns, report)
+		})
+		return
+	}
+
+	body, err := json.Marshal(report)
+	sendResponseOrError(op, err, w, body, slog.Int("periods", len(report.Buckets)))
+}
+
+// HandlerGetTaskEstimates - оценки задач в сравнении с затраченным временем
+// @Summary Task estimates versus actuals
+// @Description Estimate, actual time (including running timers) and remaining effort of tasks, with totals.
+// @Description A task is overdue when its periodTo has passed and it isn't done or cancelled, overrun when its actual time exceeds the estimate
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param filter    query string false "Filter of tasks as in GET /tasks"
+// @Param sort      query string false "Sort: comma-separated fields, '-' for descending (id, title, project_id, period_from, period_to, cost, estimate, status, completed_at, created)"
+// @Param projectId query int    false "Only tasks of the project"
+// @Param overdue   query bool   false "Only overdue (true) or not overdue (false) tasks"
+// @Param overrun   query bool   false "Only tasks over (true) or within (false) the estimate"
+// @Success 200 {object} EstimateReport
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /task-estimates [get]
+func (h *TimeTrackingService) HandlerGetTaskEstimates(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerGetTaskEstimates"
+
+	slog.Info(op)
+
+	query := r.URL.Query()
+
+	var params EstimateParams
+	var err error
+	if params.Filter, err = parseFilter(query.Get("filter")); err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+	if params.Sort, err = parseSort(query.Get("sort"), taskSortFields); err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+	if v := query.Get("projectId"); v != "" {
+		if params.ProjectId, err = parseId(v); err != nil {
+			sendResponseOrError(op, err, w, nil)
+			return
+		}
+	}
+
+	// Только просроченные или превысившие оценку задачи
+	if params.Overdue, err = parseBoolParam(query, "overdue"); err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+	if params.Overrun, err = parseBoolParam(query, "overrun"); err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	report, err := h.EstimateReport(r.Context(), params)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(report)
+	sendResponseOrError(op, err, w, body, slog.Int("tasks", len(report.Tasks)))
 }
 
 // HandlerBeginTaskForUser - начать отсчет времени по задаче
 // @Summary Begin task for user
-// @Description Begin task for user
+// @Description Begin task for user, a todo or paused task moves to in_progress. Done and cancelled tasks can't be begun
 // @Tags Time Tracking
 // @Accept json
 // @Produce json
 // @Param pasportNumber body string true "Passport number"
 // @Param taskId        body string true "Task ID"
+// @Param note          body string false "Note for the time entry"
 // @Success 200 {string} string "OK"
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача уже начата, у пользователя идет таймер по другой задаче или задача завершена"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
 // @Router /begin-task-for-user [post]
 func (h *TimeTrackingService) HandlerBeginTaskForUser(w http.ResponseWriter, r *http.Request) {
@@ -183,6 +1429,7 @@ func (h *TimeTrackingService) HandlerBeginTaskForUser(w http.ResponseWriter, r *
 	var data struct {
 		PasportSeriesNumber string `json:"pasportNumber"`
 		TaskId              int32  `json:"taskId"`
+		Note                string `json:"note"`
 	}
 	if err := json.Unmarshal(body, &data); err != nil {
 		sendResponseOrError("HandlerBeginTaskForUser", err, w, body)
@@ -197,18 +1444,20 @@ func (h *TimeTrackingService) HandlerBeginTaskForUser(w http.ResponseWriter, r *
 		return
 	}
 
-	err = h.BeginTaskForUser(seriesNumber[0], seriesNumber[1], data.TaskId)
+	err = h.BeginTaskForUser(r.Context(), seriesNumber[0], seriesNumber[1], data.TaskId, data.Note)
 	sendResponseOrError("HandlerBeginTaskForUser", err, w, nil)
 }
 
 // HandlerEndTaskForUser - закончить отсчет времени по задаче
 // @Summary Finish task time tracking
-// @Description Finish tracking time for a specific task
+// @Description Finish tracking time for a specific task, the task moves from in_progress to paused when its last timer stops
 // @Produce json
 // @Param pasportNumber body string true "Passport number"
 // @Param taskId        body string true "Task ID"
+// @Param note          body string false "Note for the time entry"
 // @Success 200 {string} string "OK"
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача не начата пользователем"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
 // @Router /end-task-for-user [post]
 func (h *TimeTrackingService) HandlerEndTaskForUser(w http.ResponseWriter, r *http.Request) {
@@ -226,6 +1475,7 @@ func (h *TimeTrackingService) HandlerEndTaskForUser(w http.ResponseWriter, r *ht
 	var data struct {
 		PasportSeriesNumber string `json:"pasportNumber"`
 		TaskId              int32  `json:"taskId"`
+		Note                string `json:"note"`
 	}
 	if err := json.Unmarshal(body, &data); err != nil {
 		sendResponseOrError(op, err, w, nil)
@@ -240,13 +1490,70 @@ func (h *TimeTrackingService) HandlerEndTaskForUser(w http.ResponseWriter, r *ht
 		return
 	}
 
-	err = h.EndTaskForUser(seriesNumber[0], seriesNumber[1], data.TaskId)
+	err = h.EndTaskForUser(r.Context(), seriesNumber[0], seriesNumber[1], data.TaskId, data.Note)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerPauseTaskForUser - приостановить отсчет времени по задаче
+// @Summary Pause task time tracking
+// @Description Pause tracking time for a started task, the work session stays open
+// @Tags Time Tracking
+// @Accept json
+// @Produce json
+// @Param pasportNumber body string true "Passport number"
+// @Param taskId        body string true "Task ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача не начата или уже приостановлена"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /pause-task-for-user [post]
+func (h *TimeTrackingService) HandlerPauseTaskForUser(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerPauseTaskForUser"
+
+	slog.Info(op)
+
+	seriesNumber, taskId, err := parseUserTask(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.PauseTaskForUser(r.Context(), seriesNumber[0], seriesNumber[1], taskId)
+	sendResponseOrError(op, err, w, nil)
+}
+
+// HandlerResumeTaskForUser - возобновить отсчет времени по задаче
+// @Summary Resume task time tracking
+// @Description Resume tracking time for a paused task
+// @Tags Time Tracking
+// @Accept json
+// @Produce json
+// @Param pasportNumber body string true "Passport number"
+// @Param taskId        body string true "Task ID"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Задача не приостановлена или у пользователя идет таймер по другой задаче"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /resume-task-for-user [post]
+func (h *TimeTrackingService) HandlerResumeTaskForUser(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerResumeTaskForUser"
+
+	slog.Info(op)
+
+	seriesNumber, taskId, err := parseUserTask(r)
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	err = h.ResumeTaskForUser(r.Context(), seriesNumber[0], seriesNumber[1], taskId)
 	sendResponseOrError(op, err, w, nil)
 }
 
 // HandlerDeleteUser - удалить пользователя
 // @Summary Delete user
-// @Description Delete user by passport series and number
+// @Description Delete user by passport series and number together with the time entries and assignments of the user.
+// @Description A user with a started task or invoiced time entries can't be deleted
 // @Tags User
 // @Accept  json
 // @Produce  json
@@ -254,6 +1561,7 @@ func (h *TimeTrackingService) HandlerEndTaskForUser(w http.ResponseWriter, r *ht
 // @Param   pasportNumber    query    string  true  "Passport number"
 // @Success 200 {string} string "OK"
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "У пользователя идет таймер или есть оплаченное время"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
 // @Router /users [delete]
 func (h *TimeTrackingService) HandlerDeleteUser(w http.ResponseWriter, r *http.Request) {
@@ -286,13 +1594,13 @@ func (h *TimeTrackingService) HandlerDeleteUser(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = h.DeleteUser(seriesNumber[0], seriesNumber[1])
+	err = h.DeleteUser(r.Context(), seriesNumber[0], seriesNumber[1])
 	sendResponseOrError(op, err, w, nil)
 }
 
 // HandlerUpdateUser - обновить данные пользователя
 // @Summary Update user data
-// @Description Update user data by passport series and number
+// @Description Update user data by passport series and number, hourlyRate - string like "1500.00", empty or null removes the rate. Only surname, name, patronymic, address, workdayEnd, timerPolicy and hourlyRate can be updated, other fields are rejected
 // @Tags User
 // @Accept  json
 // @Produce  json
@@ -333,7 +1641,7 @@ func (h *TimeTrackingService) HandlerUpdateUser(w http.ResponseWriter, r *http.R
 
 	delete(data, "pasportNumber")
 
-	err = h.UpdateInfoUser(seriesNumber[0], seriesNumber[1], data)
+	err = h.UpdateInfoUser(r.Context(), seriesNumber[0], seriesNumber[1], data)
 	if err != nil {
 		sendResponseOrError("HandlerUpdateUser", err, w, nil)
 		return
@@ -344,13 +1652,14 @@ func (h *TimeTrackingService) HandlerUpdateUser(w http.ResponseWriter, r *http.R
 
 // HandlerCreateUser - создание пользователя
 // @Summary Create user
-// @Description Create user with passport data
+// @Description Create user with passport data, the id of an existing user with the same passport is returned
 // @Tags User
 // @Accept  json
 // @Produce  json
 // @Param   body     body    User   true        "User data"
 // @Success 200 {int32} int32 0
 // @Failure 400 {string} error "Неверные параметры запроса"
+// @Failure 409 {string} error "Пользователь с этим паспортом создается параллельным запросом"
 // @Failure 500 {string} error "Внутренняя ошибка сервера"
 // @Router /users [post]
 func (h *TimeTrackingService) HandlerCreateUser(w http.ResponseWriter, r *http.Request) {
@@ -381,6 +1690,30 @@ func (h *TimeTrackingService) HandlerCreateUser(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	newId, err := h.CreateUser(seriesNumber[0], seriesNumber[1])
+	newId, err := h.CreateUser(r.Context(), seriesNumber[0], seriesNumber[1])
 	sendResponseOrError("HandlerCreateUser", err, w, []byte(fmt.Sprintf(`{"id": %d}`, newId)))
 }
+
+// HandlerPoolStats - статистика пула соединений хранилища
+// @Summary Storage connection pool statistics
+// @Description Get connection pool statistics of the storage
+// @Tags Service
+// @Produce  json
+// @Success 200 {object} storage.PoolStats
+// @Failure 400 {string} error "Хранилище без пула соединений"
+// @Failure 500 {string} error "Внутренняя ошибка сервера"
+// @Router /pool-stats [get]
+func (h *TimeTrackingService) HandlerPoolStats(w http.ResponseWriter, r *http.Request) {
+	const op = "TimeTrackingService: HandlerPoolStats"
+
+	slog.Info(op)
+
+	stats, err := h.PoolStats(r.Context())
+	if err != nil {
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	body, err := json.Marshal(stats)
+	sendResponseOrError(op, err, w, body, slog.Any("stats", stats))
+}
@@ -0,0 +1,468 @@
+package timetracking
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	. "timetracking/storage"
+)
+
+// invoiceNumberPrefix - начало номера счета с годом, номер - порядковый в году, например INV-2024-0001
+const invoiceNumberPrefix = "INV-%d-"
+
+// Параметры выставления счета
+type InvoiceParams struct {
+	Client     string        `json:"client"`     // клиент: время всех его проектов
+	ProjectId  int32         `json:"projectId"`  // проект, 0 - все проекты клиента
+	PeriodFrom time.Time     `json:"periodFrom"` // начало периода, включительно
+	PeriodTo   time.Time     `json:"periodTo"`   // конец периода, не включительно
+	GroupBy    ReportGroupBy `json:"groupBy"`    // строки по задачам (task, по умолчанию) или пользователям (user)
+}
+
+// validate - проверка параметров
+func (p *InvoiceParams) validate() error {
+	p.Client = strings.TrimSpace(p.Client)
+	if p.Client == "" && p.ProjectId == 0 {
+		return &InvalidError{"client or projectId is required"}
+	}
+
+	if p.PeriodFrom.IsZero() || p.PeriodTo.IsZero() {
+		return &InvalidError{"periodFrom and periodTo are required"}
+	}
+	if !p.PeriodFrom.Before(p.PeriodTo) {
+		return &InvalidError{"periodFrom must be before periodTo"}
+	}
+
+	switch p.GroupBy {
+	case "":
+		p.GroupBy = ReportByTask
+	case ReportByTask, ReportByUser:
+	default:
+		return &InvalidError{fmt.Sprintf("unknown groupBy %q, expected task or user", p.GroupBy)}
+	}
+
+	return nil
+}
+
+// Счет за оплачиваемое время
+type Invoice struct {
+	Id         int32         `json:"id" db:"id"`
+	Number     string        `json:"number" db:"number"`                       // номер счета
+	Client     string        `json:"client" db:"client,null"`                  // клиент
+	ProjectId  int32         `json:"projectId,omitempty" db:"project_id,null"` // проект, 0 - все проекты клиента
+	PeriodFrom time.Time     `json:"periodFrom" db:"period_from"`              // начало периода
+	PeriodTo   time.Time     `json:"periodTo" db:"period_to"`                  // конец периода, не включительно
+	GroupBy    ReportGroupBy `json:"groupBy" db:"group_by"`                    // строки по задачам или пользователям
+	Currency   string        `json:"currency" db:"currency"`                   // валюта счета
+	Seconds    int64         `json:"seconds" db:"seconds"`                     // время по счету в секундах
+	Duration   string        `json:"duration"`                                 // время по счету, например "1h 05m 09s"
+	Amount     Amount        `json:"amount" db:"amount" swaggertype:"string"`  // сумма счета
+
+	Created time.Time `json:"created" db:"created"` // дата выставления
+
+	Lines []*InvoiceLine `json:"lines,omitempty"` // строки счета
+}
+
+// Строка счета: время задачи или пользователя по одной ставке
+type InvoiceLine struct {
+	Id          int32  `json:"id" db:"id"`
+	InvoiceId   int32  `json:"invoiceId" db:"invoice_id"`                        // счет
+	TaskId      int32  `json:"taskId,omitempty" db:"task_id,null"`               // задача, при строках по задачам
+	UserId      int32  `json:"userId,omitempty" db:"user_id,null"`               // пользователь, при строках по пользователям
+	Description string `json:"description" db:"description"`                     // название задачи или ФИО пользователя
+	HourlyRate  Amount `json:"hourlyRate" db:"hourly_rate" swaggertype:"string"` // почасовая ставка
+	Seconds     int64  `json:"seconds" db:"seconds"`                             // время в секундах
+	Duration    string `json:"duration"`                                         // время, например "1h 05m 09s"
+	Amount      Amount `json:"amount" db:"amount" swaggertype:"string"`          // сумма строки
+
+	spent time.Duration
+}
+
+// Находит счета по фильтру, без строк
+func (s *TimeTrackingService) FindInvoicesByFilter(ctx context.Context, filter Filter, sort []Sort, page Pagination) ([]*Invoice, error) {
+	const op = "TimeTrackingService: FindInvoicesByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	filter, sort, err := paginate(filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := s.storage.Select(ctx, InvoiceCollection, filter, sort, page.Limit, page.Offset)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	invoices, err := ReadAll[Invoice](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	for _, invoice := range invoices {
+		invoice.Duration = formatDuration(time.Duration(invoice.Seconds) * time.Second)
+	}
+
+	Logger.Debug("TimeTrackingService: FindInvoicesByFilter invoices found", slog.Int("count", len(invoices)))
+	return invoices, nil
+}
+
+// CountInvoicesByFilter - количество счетов по фильтру
+func (s *TimeTrackingService) CountInvoicesByFilter(ctx context.Context, filter Filter) (int64, error) {
+	const op = "TimeTrackingService: CountInvoicesByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter))
+
+	count, err := s.storage.Count(ctx, InvoiceCollection, filter)
+	if err != nil {
+		return 0, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: CountInvoicesByFilter invoices counted", slog.Int64("count", count))
+	return count, nil
+}
+
+// Находит счет по идентификатору вместе со строками
+func (s *TimeTrackingService) FindInvoiceById(ctx context.Context, id int32) (*Invoice, error) {
+	const op = "TimeTrackingService: FindInvoiceById"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	invoices, err := s.FindInvoicesByFilter(ctx, Match{"id": id}, nil, Pagination{Limit: 1})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	if len(invoices) == 0 {
+		Logger.Info(op+" failed", slog.String("error", "invoice not found"))
+		return nil, &NotFoundError{"invoice not found"}
+	}
+	invoice := invoices[0]
+
+	reader, err := s.storage.Select(ctx, InvoiceLineCollection, Eq("invoice_id", invoice.Id), []Sort{{Field: "id"}}, 0, 0)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+	invoice.Lines, err = ReadAll[InvoiceLine](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+	for _, line := range invoice.Lines {
+		line.Duration = formatDuration(time.Duration(line.Seconds) * time.Second)
+	}
+
+	return invoice, nil
+}
+
+// nextInvoiceNumber - следующий номер счета в году now. Номера сравниваются по числу после префикса,
+// строки не подходят: INV-2026-10000 меньше INV-2026-9999
+func (s *TimeTrackingService) nextInvoiceNumber(ctx context.Context, now time.Time) (string, error) {
+	prefix := fmt.Sprintf(invoiceNumberPrefix, now.Year())
+
+	invoices, err := s.FindInvoicesByFilter(ctx, Like("number", prefix+"%"), nil, Pagination{})
+	if err != nil {
+		return "", err
+	}
+
+	last := 0
+	for _, invoice := range invoices {
+		n, err := strconv.Atoi(strings.TrimPrefix(invoice.Number, prefix))
+		if err != nil {
+			return "", fmt.Errorf("timetracking: invalid invoice number %s: %w", invoice.Number, err)
+		}
+		last = max(last, n)
+	}
+
+	return fmt.Sprintf("%s%04d", prefix, last+1), nil
+}
+
+// invoiceProjects - проекты счета: проект из параметров или все проекты клиента
+func (s *TimeTrackingService) invoiceProjects(ctx context.Context, params InvoiceParams) ([]*Project, error) {
+	if params.ProjectId != 0 {
+		project, err := s.FindProjectById(ctx, params.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+		if params.Client != "" && project.Client != params.Client {
+			return nil, &InvalidError{"project belongs to another client"}
+		}
+		return []*Project{project}, nil
+	}
+
+	projects, err := s.FindProjectsByFilter(ctx, Eq("client", params.Client), nil, Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, &InvalidError{"client has no projects"}
+	}
+	return projects, nil
+}
+
+// Выставление счета: неоплаченное оплачиваемое время проектов клиента или проекта, начатое в периоде,
+// собирается в строки по задачам или пользователям. Интервалы счета больше нельзя менять и удалять.
+// Учитываются только завершенные интервалы, у оплачиваемого времени должна быть ставка
+func (s *TimeTrackingService) CreateInvoice(ctx context.Context, params InvoiceParams) (int32, error) {
+	const op = "TimeTrackingService: CreateInvoice"
+
+	Logger.Debug(op, slog.Any("params", params))
+
+	if err := params.validate(); err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	var newId int32
+	err := s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		projects, err := tx.invoiceProjects(ctx, params)
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+		client := params.Client
+		if client == "" {
+			client = projects[0].Client
+		}
+
+		projectIds := make([]int32, len(projects))
+		for i, project := range projects {
+			projectIds[i] = project.Id
+		}
+		tasks, err := tx.FindTasksByFilter(ctx, In("project_id", projectIds...), nil, Pagination{})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		// Неоплаченные интервалы задач проектов, интервалы блокируются до конца транзакции
+		var entries []*TimeEntry
+		if len(tasks) > 0 {
+			taskIds := make([]int32, len(tasks))
+			for i, task := range tasks {
+				taskIds[i] = task.Id
+			}
+			filter := And(
+				In("task_id", taskIds...),
+				IsNull("invoice_id"),
+				IsNotNull("ended_at"),
+				Gte("started_at", params.PeriodFrom.UTC()),
+				Lt("started_at", params.PeriodTo.UTC()),
+			)
+			entries, err = tx.FindTimeEntriesByFilter(ctx, filter, []Sort{{Field: "started_at"}, {Field: "id"}}, Pagination{})
+			if err != nil {
+				return processStorageError(op, err, false)
+			}
+		}
+
+		book, err := tx.newRateBook(ctx, entries)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		// Строки счета по задаче или пользователю и ставке
+		type lineKey struct {
+			id   int32
+			rate string
+		}
+		lines := map[lineKey]*InvoiceLine{}
+		var entryIds []int32
+		currency := ""
+		for _, entry := range entries {
+			rate := book.rate(entry)
+			if !rate.Billable {
+				continue
+			}
+			if !rate.Rate.Valid {
+				Logger.Info(op+" failed", slog.String("error", "no hourly rate"), slog.Int("taskId", int(entry.TaskId)))
+				return &InvalidError{fmt.Sprintf("task %d has no hourly rate, set a rate or make the task non-billable", entry.TaskId)}
+			}
+			if currency == "" {
+				currency = rate.Currency
+			} else if currency != rate.Currency {
+				Logger.Info(op+" failed", slog.String("error", "several currencies"))
+				return &InvalidError{fmt.Sprintf("time is billed in %s and %s, invoice the projects separately", currency, rate.Currency)}
+			}
+
+			key := lineKey{id: entry.TaskId, rate: rate.Rate.Decimal.String()}
+			if params.GroupBy == ReportByUser {
+				key.id = entry.UserId
+			}
+			line, ok := lines[key]
+			if !ok {
+				line = &InvoiceLine{HourlyRate: Amount{rate.Rate.Decimal}}
+				if params.GroupBy == ReportByUser {
+					line.UserId = key.id
+				} else {
+					line.TaskId = key.id
+				}
+				lines[key] = line
+			}
+			line.spent += entry.DurationIn(entry.StartedAt, *entry.EndedAt, *entry.EndedAt)
+			entryIds = append(entryIds, entry.Id)
+		}
+
+		if len(entryIds) == 0 {
+			Logger.Info(op+" failed", slog.String("error", "nothing to invoice"))
+			return &InvalidError{"no unbilled billable time for the period"}
+		}
+
+		// Суммы строк округляются до копеек, сумма счета - сумма строк
+		ids := make([]int32, 0, len(lines))
+		for key := range lines {
+			ids = append(ids, key.id)
+		}
+		names, err := tx.reportNames(ctx, params.GroupBy, ids)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		sorted := make([]*InvoiceLine, 0, len(lines))
+		var spent time.Duration
+		total := decimal.Zero
+		for key, line := range lines {
+			line.Description = names[key.id]
+			if line.Description == "" {
+				line.Description = fmt.Sprintf("%s %d", params.GroupBy, key.id)
+			}
+			line.Seconds = int64(line.spent / time.Second)
+			line.Amount = amountOf(line.HourlyRate.Decimal, line.spent)
+			spent += line.spent
+			total = total.Add(line.Amount.Decimal)
+			sorted = append(sorted, line)
+		}
+		slices.SortFunc(sorted, func(a, b *InvoiceLine) int {
+			if c := cmp.Compare(a.Description, b.Description); c != 0 {
+				return c
+			}
+			return a.HourlyRate.Cmp(b.HourlyRate.Decimal)
+		})
+
+		number, err := tx.nextInvoiceNumber(ctx, time.Now().UTC())
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		invoiceData := map[string]any{
+			"number":      number,
+			"client":      client,
+			"project_id":  nil,
+			"period_from": params.PeriodFrom.UTC(),
+			"period_to":   params.PeriodTo.UTC(),
+			"group_by":    string(params.GroupBy),
+			"currency":    currency,
+			"seconds":     int64(spent / time.Second),
+			"amount":      Amount{total}.String(),
+		}
+		if params.ProjectId != 0 {
+			invoiceData["project_id"] = params.ProjectId
+		}
+		// Параллельно созданный счет мог занять тот же номер
+		newId, err = tx.storage.Insert(ctx, InvoiceCollection, invoiceData)
+		if errors.Is(err, ErrDuplicate) {
+			Logger.Info(op+" failed", slog.String("error", "invoice number is already used"), slog.String("number", number))
+			return &ConflictError{fmt.Sprintf("invoice number %s is already used, retry creating the invoice", number)}
+		}
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		for _, line := range sorted {
+			lineData := map[string]any{
+				"invoice_id":  newId,
+				"task_id":     nil,
+				"user_id":     nil,
+				"description": line.Description,
+				"hourly_rate": line.HourlyRate.String(),
+				"seconds":     line.Seconds,
+				"amount":      line.Amount.String(),
+			}
+			if line.TaskId != 0 {
+				lineData["task_id"] = line.TaskId
+			}
+			if line.UserId != 0 {
+				lineData["user_id"] = line.UserId
+			}
+			if _, err := tx.storage.Insert(ctx, InvoiceLineCollection, lineData); err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// Интервалы счета блокируются от изменений
+		err = tx.storage.Update(ctx, TimeEntryCollection, In("id", entryIds...), map[string]any{"invoice_id": newId})
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: CreateInvoice invoice created", slog.Int("invoiceId", int(newId)), slog.String("number", number), slog.Int("entries", len(entryIds)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newId, nil
+}
+
+// checkNotInvoiced - интервал не входит в счет, иначе его нельзя менять
+func checkNotInvoiced(entry *TimeEntry) error {
+	if entry.InvoiceId != 0 {
+		return &ConflictError{fmt.Sprintf("time entry %d is invoiced (invoice %d) and can't be changed", entry.Id, entry.InvoiceId)}
+	}
+	return nil
+}
+
+// errInvoicePDF - счет в PDF сервис не формирует, есть только JSON и HTML
+var errInvoicePDF = &InvalidError{"pdf format is not supported, get the invoice with format=html and print it in the browser"}
+
+// invoiceTemplate - счет для просмотра и печати в браузере
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format(time.DateOnly) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.4em; text-align: left; }
+td.num, th.num { text-align: right; }
+tfoot td { font-weight: bold; border-bottom: none; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Date: {{date .Created}}<br>
+Client: {{.Client}}<br>
+Period: {{date .PeriodFrom}} &ndash; {{date .PeriodTo}} (exclusive)</p>
+<table>
+<thead>
+<tr><th>Description</th><th class="num">Time</th><th class="num">Rate, {{.Currency}}/h</th><th class="num">Amount, {{.Currency}}</th></tr>
+</thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.Description}}</td><td class="num">{{.Duration}}</td><td class="num">{{.HourlyRate}}</td><td class="num">{{.Amount}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td>Total</td><td class="num">{{.Duration}}</td><td></td><td class="num">{{.Amount}} {{.Currency}}</td></tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// writeInvoiceHTML - счет в HTML
+func writeInvoiceHTML(w io.Writer, invoice *Invoice) error {
+	return invoiceTemplate.Execute(w, invoice)
+}
@@ -0,0 +1,179 @@
+package timetracking
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// Pagination - параметры страницы: Limit вместе с Offset или с курсором After
+type Pagination struct {
+	Limit  int
+	Offset int
+	After  *Cursor // nil - страницы по Offset
+}
+
+// Page - метаданные страницы списка
+type Page struct {
+	Total      int64  `json:"total"`                // всего записей по фильтру
+	Limit      int    `json:"limit"`                // размер страницы, 0 - без ограничения
+	Offset     int    `json:"offset"`               // смещение страницы
+	Next       string `json:"next,omitempty"`       // ссылка на следующую страницу
+	Prev       string `json:"prev,omitempty"`       // ссылка на предыдущую страницу
+	NextCursor string `json:"nextCursor,omitempty"` // курсор следующей страницы для параметра after
+}
+
+// UsersPage - страница пользователей
+type UsersPage struct {
+	Page
+	Users []*User `json:"users"`
+}
+
+// TasksPage - страница задач
+type TasksPage struct {
+	Page
+	Tasks []*Task `json:"tasks"`
+}
+
+// ProjectsPage - страница проектов
+type ProjectsPage struct {
+	Page
+	Projects []*Project `json:"projects"`
+}
+
+// InvoicesPage - страница счетов
+type InvoicesPage struct {
+	Page
+	Invoices []*Invoice `json:"invoices"`
+}
+
+// TimeEntriesPage - страница интервалов работы
+type TimeEntriesPage struct {
+	Page
+	TimeEntries []*TimeEntry `json:"timeEntries"`
+}
+
+// cursorOrder - направление чтения по курсору, ok - сортировка допускает курсор:
+// только по created, а при заданном курсоре еще и без сортировки
+func cursorOrder(sort []Sort, after *Cursor) (desc bool, ok bool) {
+	if len(sort) == 0 {
+		if after == nil {
+			return false, false
+		}
+		return after.Desc, true
+	}
+
+	if len(sort) != 1 || sort[0].Field != "created" {
+		return false, false
+	}
+	if after != nil && after.Desc != sort[0].Desc {
+		return false, false
+	}
+	return sort[0].Desc, true
+}
+
+// paginate - фильтр и сортировка запроса страницы с учетом курсора
+func paginate(filter Filter, sort []Sort, page Pagination) (Filter, []Sort, error) {
+	if page.After == nil {
+		return filter, stableSort(sort), nil
+	}
+
+	if page.Offset != 0 {
+		return nil, nil, &InvalidError{"after can't be used with offset"}
+	}
+
+	desc, ok := cursorOrder(sort, page.After)
+	if !ok {
+		return nil, nil, &InvalidError{"after requires sort by created"}
+	}
+
+	return And(filter, page.After.Filter()), CursorSort(desc), nil
+}
+
+// nextCursor - курсор следующей страницы по последней записи,
+// nil - страница неполная или сортировка не допускает курсор
+func nextCursor(sort []Sort, page Pagination, count int, created time.Time, id int32) *Cursor {
+	if page.Limit <= 0 || count < page.Limit {
+		return nil
+	}
+
+	desc, ok := cursorOrder(sort, page.After)
+	if !ok {
+		return nil
+	}
+
+	return &Cursor{Created: created, Id: id, Desc: desc}
+}
+
+// parsePagination - параметры страницы из запроса: limit, offset и after
+func parsePagination(r *http.Request) (Pagination, error) {
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	page := Pagination{Limit: limit, Offset: offset}
+
+	if afterS := query.Get("after"); afterS != "" {
+		after, err := DecodeCursor(afterS)
+		if err != nil {
+			return page, errors.Join(&InvalidError{"invalid after"}, err)
+		}
+		page.After = after
+	}
+
+	return page, nil
+}
+
+// newPage - метаданные страницы, ссылки строятся от адреса запроса с замененными параметрами страницы.
+// При наличии курсора следующая страница выбирается по нему, предыдущая по курсору недоступна
+func newPage(r *http.Request, total int64, page Pagination, next *Cursor) Page {
+	result := Page{Total: total, Limit: page.Limit, Offset: page.Offset}
+	if page.Limit <= 0 {
+		return result
+	}
+
+	if next != nil {
+		result.NextCursor = next.Encode()
+		result.Next = pageURL(r, page.Limit, 0, result.NextCursor)
+	} else if page.After == nil && int64(page.Offset+page.Limit) < total {
+		result.Next = pageURL(r, page.Limit, page.Offset+page.Limit, "")
+	}
+
+	if page.After == nil && page.Offset > 0 {
+		result.Prev = pageURL(r, page.Limit, max(page.Offset-page.Limit, 0), "")
+	}
+
+	return result
+}
+
+func pageURL(r *http.Request, limit, offset int, after string) string {
+	query := r.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Del("offset")
+	query.Del("after")
+	if after != "" {
+		query.Set("after", after)
+	} else {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// setLinkHeader - заголовок Link (RFC 8288) со ссылками на соседние страницы
+func (p Page) setLinkHeader(w http.ResponseWriter) {
+	var links []string
+	if p.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next))
+	}
+	if p.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.Prev))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,412 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+
+	. "timetracking/storage"
+)
+
+// Ограничения длины полей проекта, совпадают с размерами колонок
+const (
+	maxProjectNameLength   = 100
+	maxProjectCodeLength   = 20
+	maxProjectClientLength = 100
+)
+
+// Проект, объединяющий задачи
+type Project struct {
+	Id         int32     `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`                   // название
+	Code       string    `json:"code" db:"code"`                   // короткий уникальный код
+	Client     string    `json:"client" db:"client,null"`          // клиент
+	Active     bool      `json:"active" db:"active"`               // в проекте можно вести задачи
+	PeriodFrom time.Time `json:"periodFrom" db:"period_from,null"` // начало проекта
+	PeriodTo   time.Time `json:"periodTo" db:"period_to,null"`     // конец проекта
+
+	HourlyRate decimal.NullDecimal `json:"hourlyRate" db:"hourly_rate" swaggertype:"string"` // почасовая ставка задач без своей ставки
+	Currency   string              `json:"currency,omitempty" db:"currency,null"`            // валюта ставок, пусто - валюта по умолчанию
+	Billable   bool                `json:"billable" db:"billable"`                           // время задач оплачивается клиентом
+
+	Tasks    int64         `json:"tasks"`                      // количество задач проекта
+	Cost     time.Duration `json:"cost" swaggertype:"integer"` // потраченное время по всем задачам проекта
+	Duration string        `json:"duration"`                   // потраченное время, например "1h 05m 09s"
+
+	Created time.Time `json:"created" db:"created"` // дата создания
+}
+
+// Поля проекта для создания и изменения, nil - поле не задано
+type ProjectData struct {
+	Name       *string    `json:"name"`       // название
+	Code       *string    `json:"code"`       // короткий уникальный код
+	Client     *string    `json:"client"`     // клиент
+	Active     *bool      `json:"active"`     // в проекте можно вести задачи
+	PeriodFrom *time.Time `json:"periodFrom"` // начало проекта
+	PeriodTo   *time.Time `json:"periodTo"`   // конец проекта
+	HourlyRate *string    `json:"hourlyRate"` // почасовая ставка, например "1500.00", пусто - ставка не задана
+	Currency   *string    `json:"currency"`   // валюта ставок ISO 4217, пусто - валюта по умолчанию
+	Billable   *bool      `json:"billable"`   // время задач оплачивается клиентом, по умолчанию да
+}
+
+// validate - проверка заданных полей, при создании обязательны название и код
+func (d *ProjectData) validate(create bool) error {
+	if d.Name != nil {
+		name := strings.TrimSpace(*d.Name)
+		if name == "" {
+			return &InvalidError{"name is empty"}
+		}
+		if utf8.RuneCountInString(name) > maxProjectNameLength {
+			return &InvalidError{fmt.Sprintf("name is longer than %d characters", maxProjectNameLength)}
+		}
+		d.Name = &name
+	} else if create {
+		return &InvalidError{"name is required"}
+	}
+
+	if d.Code != nil {
+		code := strings.TrimSpace(*d.Code)
+		if code == "" || strings.ContainsAny(code, " \t") {
+			return &InvalidError{"code is empty or contains spaces"}
+		}
+		if utf8.RuneCountInString(code) > maxProjectCodeLength {
+			return &InvalidError{fmt.Sprintf("code is longer than %d characters", maxProjectCodeLength)}
+		}
+		d.Code = &code
+	} else if create {
+		return &InvalidError{"code is required"}
+	}
+
+	if d.Client != nil && utf8.RuneCountInString(*d.Client) > maxProjectClientLength {
+		return &InvalidError{fmt.Sprintf("client is longer than %d characters", maxProjectClientLength)}
+	}
+
+	if d.HourlyRate != nil {
+		if _, err := rateField(*d.HourlyRate); err != nil {
+			return err
+		}
+	}
+
+	if d.Currency != nil && strings.TrimSpace(*d.Currency) != "" {
+		currency, err := ParseCurrency(*d.Currency)
+		if err != nil {
+			return err
+		}
+		d.Currency = &currency
+	}
+
+	return nil
+}
+
+// fields - колонки проекта для заданных полей
+func (d *ProjectData) fields() map[string]any {
+	fields := map[string]any{}
+	if d.Name != nil {
+		fields["name"] = *d.Name
+	}
+	if d.Code != nil {
+		fields["code"] = *d.Code
+	}
+	if d.Client != nil {
+		fields["client"] = *d.Client
+	}
+	if d.Active != nil {
+		fields["active"] = *d.Active
+	}
+	if d.PeriodFrom != nil {
+		fields["period_from"] = d.PeriodFrom.UTC()
+	}
+	if d.PeriodTo != nil {
+		fields["period_to"] = d.PeriodTo.UTC()
+	}
+	if d.HourlyRate != nil {
+		fields["hourly_rate"], _ = rateField(*d.HourlyRate)
+	}
+	if d.Currency != nil {
+		if currency := strings.TrimSpace(*d.Currency); currency == "" {
+			fields["currency"] = nil
+		} else {
+			fields["currency"] = currency
+		}
+	}
+	if d.Billable != nil {
+		fields["billable"] = *d.Billable
+	}
+	return fields
+}
+
+// Находит проекты по фильтру
+func (s *TimeTrackingService) FindProjectsByFilter(ctx context.Context, filter Filter, sort []Sort, page Pagination) ([]*Project, error) {
+	const op = "TimeTrackingService: FindProjectsByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	filter, sort, err := paginate(filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := s.storage.Select(ctx, ProjectCollection, filter, sort, page.Limit, page.Offset)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	projects, err := ReadAll[Project](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: FindProjectsByFilter projects found", slog.Int("count", len(projects)))
+	return projects, nil
+}
+
+// CountProjectsByFilter - количество проектов по фильтру
+func (s *TimeTrackingService) CountProjectsByFilter(ctx context.Context, filter Filter) (int64, error) {
+	const op = "TimeTrackingService: CountProjectsByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter))
+
+	count, err := s.storage.Count(ctx, ProjectCollection, filter)
+	if err != nil {
+		return 0, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: CountProjectsByFilter projects counted", slog.Int64("count", count))
+	return count, nil
+}
+
+// Находит проект по идентификатору
+func (s *TimeTrackingService) FindProjectById(ctx context.Context, id int32) (*Project, error) {
+	const op = "TimeTrackingService: FindProjectById"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	projects, err := s.FindProjectsByFilter(ctx, Match{"id": id}, nil, Pagination{Limit: 1})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	if len(projects) == 0 {
+		Logger.Info(op+" failed", slog.String("error", "project not found"))
+		return nil, &NotFoundError{"project not found"}
+	}
+
+	return projects[0], nil
+}
+
+// FillProjectCosts - заполнить количество задач и общее время проектов по их задачам
+func (s *TimeTrackingService) FillProjectCosts(ctx context.Context, projects []*Project) error {
+	const op = "TimeTrackingService: FillProjectCosts"
+
+	if len(projects) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(projects))
+	byId := make(map[int32]*Project, len(projects))
+	for i, project := range projects {
+		ids[i] = project.Id
+		byId[project.Id] = project
+		project.Tasks, project.Cost = 0, 0
+	}
+
+	tasks, err := s.FindTasksByFilter(ctx, In("project_id", ids...), nil, Pagination{})
+	if err != nil {
+		return processStorageError(op, err, false)
+	}
+
+	for _, task := range tasks {
+		project := byId[task.ProjectId]
+		project.Tasks++
+		project.Cost += task.Cost
+	}
+
+	for _, project := range projects {
+		project.Duration = formatDuration(project.Cost)
+	}
+
+	return nil
+}
+
+// checkProjectCode - код проекта не занят другим проектом
+func (s *TimeTrackingService) checkProjectCode(ctx context.Context, code string, exceptId int32) error {
+	projects, err := s.FindProjectsByFilter(ctx, And(Eq("code", code), Neq("id", exceptId)), nil, Pagination{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(projects) > 0 {
+		return projectCodeUsed(code)
+	}
+	return nil
+}
+
+// projectCodeUsed - код проекта занят, в том числе параллельно созданным проектом
+func projectCodeUsed(code string) error {
+	return &ConflictError{fmt.Sprintf("project code %s is already used", code)}
+}
+
+// checkTaskProject - проект задачи существует и активен, 0 - задача без проекта
+func (s *TimeTrackingService) checkTaskProject(ctx context.Context, projectId int32) error {
+	if projectId == 0 {
+		return nil
+	}
+
+	project, err := s.FindProjectById(ctx, projectId)
+	if err != nil {
+		return err
+	}
+	if !project.Active {
+		return &InvalidError{"project is not active"}
+	}
+	return nil
+}
+
+// Создание проекта
+func (s *TimeTrackingService) CreateProject(ctx context.Context, data ProjectData) (int32, error) {
+	const op = "TimeTrackingService: CreateProject"
+
+	Logger.Debug(op, slog.Any("data", data))
+
+	var from, to time.Time
+	if data.PeriodFrom != nil {
+		from = *data.PeriodFrom
+	}
+	if data.PeriodTo != nil {
+		to = *data.PeriodTo
+	}
+
+	err := data.validate(true)
+	if err == nil {
+		err = validatePeriod(from, to)
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	var newId int32
+	err = s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		err := tx.checkProjectCode(ctx, *data.Code, 0)
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		projectData := data.fields()
+		if data.Active == nil {
+			projectData["active"] = true
+		}
+		if data.Billable == nil {
+			projectData["billable"] = true
+		}
+
+		newId, err = tx.storage.Insert(ctx, ProjectCollection, projectData)
+		if errors.Is(err, ErrDuplicate) {
+			Logger.Info(op+" failed", slog.String("error", "project code is already used"))
+			return projectCodeUsed(*data.Code)
+		}
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: CreateProject project created", slog.Int("projectId", int(newId)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newId, nil
+}
+
+// Изменение проекта, меняются только заданные поля
+func (s *TimeTrackingService) UpdateProject(ctx context.Context, id int32, data ProjectData) error {
+	const op = "TimeTrackingService: UpdateProject"
+
+	Logger.Debug(op, slog.Int("id", int(id)), slog.Any("data", data))
+
+	err := data.validate(false)
+	update := data.fields()
+	if err == nil && len(update) == 0 {
+		err = &InvalidError{"nothing to update"}
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск проекта, проект блокируется до конца транзакции
+		project, err := tx.FindProjectById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Период проверяется вместе с незаданной границей из проекта
+		from, to := project.PeriodFrom, project.PeriodTo
+		if data.PeriodFrom != nil {
+			from = *data.PeriodFrom
+		}
+		if data.PeriodTo != nil {
+			to = *data.PeriodTo
+		}
+		err = validatePeriod(from, to)
+		if err == nil && data.Code != nil {
+			err = tx.checkProjectCode(ctx, *data.Code, project.Id)
+		}
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		err = tx.storage.Update(ctx, ProjectCollection, Match{"id": id}, update)
+		if errors.Is(err, ErrDuplicate) && data.Code != nil {
+			Logger.Info(op+" failed", slog.String("error", "project code is already used"))
+			return projectCodeUsed(*data.Code)
+		}
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: UpdateProject project updated", slog.Int("projectId", int(id)))
+		return nil
+	})
+}
+
+// Удаление проекта, проект с задачами удалить нельзя
+func (s *TimeTrackingService) DeleteProject(ctx context.Context, id int32) error {
+	const op = "TimeTrackingService: DeleteProject"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск проекта, проект блокируется до конца транзакции
+		project, err := tx.FindProjectById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		tasks, err := tx.CountTasksByFilter(ctx, Eq("project_id", project.Id))
+		if err != nil {
+			return err
+		}
+		if tasks > 0 {
+			Logger.Info(op+" failed", slog.String("error", "project has tasks"))
+			return &InvalidError{"project has tasks, move or delete them before deleting the project"}
+		}
+
+		err = tx.storage.Delete(ctx, ProjectCollection, project.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: DeleteProject project deleted", slog.Int("projectId", int(project.Id)))
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"timetracking/memory"
+)
+
+func TestProjectCodeUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewTimeTrackingService(memory.NewMemoryStorage())
+
+	project := func(code string) ProjectData {
+		name := "Проект " + code
+		return ProjectData{Name: &name, Code: &code}
+	}
+	if _, err := s.CreateProject(ctx, project("A1")); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	id, err := s.CreateProject(ctx, project("B1"))
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	used, own := "A1", "B1"
+	tests := []struct {
+		name     string
+		call     func() error
+		conflict bool
+	}{
+		{name: "create with used code", call: func() error { _, err := s.CreateProject(ctx, project("A1")); return err }, conflict: true},
+		{name: "update to used code", call: func() error { return s.UpdateProject(ctx, id, ProjectData{Code: &used}) }, conflict: true},
+		{name: "update to own code", call: func() error { return s.UpdateProject(ctx, id, ProjectData{Code: &own}) }},
+		{name: "create with new code", call: func() error { _, err := s.CreateProject(ctx, project("C1")); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if tt.conflict {
+				if !errors.Is(err, &ConflictError{}) {
+					t.Errorf("error = %v, want ConflictError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package timetracking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	. "timetracking/storage"
+)
+
+// DefaultCurrency - валюта ставок задач без проекта и проектов без своей валюты
+const DefaultCurrency = "RUB"
+
+// Ограничения почасовой ставки, совпадают с колонкой numeric(12,2)
+const (
+	rateDecimalPlaces = 2
+	maxRateDigits     = 10 // цифр до запятой
+)
+
+// moneyDecimalPlaces - точность денежных сумм в отчетах
+const moneyDecimalPlaces = 2
+
+// WithCurrency - валюта по умолчанию для ставок задач без проекта и проектов без своей валюты
+func WithCurrency(currency string) Option {
+	return func(s *TimeTrackingService) {
+		s.currency = currency
+	}
+}
+
+// ParseCurrency - код валюты ISO 4217 из трех латинских букв, например RUB
+func ParseCurrency(s string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(s))
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", &InvalidError{fmt.Sprintf("invalid currency %q, expected ISO 4217 code like RUB", s)}
+	}
+	return currency, nil
+}
+
+// parseRate - почасовая ставка: неотрицательное число не больше чем с двумя знаками после запятой
+func parseRate(s string) (decimal.Decimal, error) {
+	rate, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return decimal.Decimal{}, &InvalidError{fmt.Sprintf("invalid hourlyRate %q", s)}
+	}
+	if rate.IsNegative() {
+		return decimal.Decimal{}, &InvalidError{"hourlyRate is negative"}
+	}
+	if !rate.Equal(rate.Truncate(rateDecimalPlaces)) {
+		return decimal.Decimal{}, &InvalidError{fmt.Sprintf("hourlyRate has more than %d decimal places", rateDecimalPlaces)}
+	}
+	if rate.GreaterThanOrEqual(decimal.New(1, maxRateDigits)) {
+		return decimal.Decimal{}, &InvalidError{fmt.Sprintf("hourlyRate has more than %d integer digits", maxRateDigits)}
+	}
+	return rate, nil
+}
+
+// rateField - значение колонки ставки: пустая строка - ставка не задана
+func rateField(s string) (any, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	rate, err := parseRate(s)
+	if err != nil {
+		return nil, err
+	}
+	return rate.StringFixed(rateDecimalPlaces), nil
+}
+
+// Money - денежные суммы по валютам, в JSON - {"RUB": "1500.00"}
+type Money map[string]decimal.Decimal
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amounts := make(map[string]string, len(m))
+	for currency, amount := range m {
+		amounts[currency] = amount.StringFixed(moneyDecimalPlaces)
+	}
+	return json.Marshal(amounts)
+}
+
+// String - суммы через запятую по алфавиту валют, например "1500.00 RUB, 20.00 USD"
+func (m Money) String() string {
+	currencies := make([]string, 0, len(m))
+	for currency := range m {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+
+	amounts := make([]string, len(currencies))
+	for i, currency := range currencies {
+		amounts[i] = m[currency].StringFixed(moneyDecimalPlaces) + " " + currency
+	}
+	return strings.Join(amounts, ", ")
+}
+
+// Amount - денежная сумма, в JSON строкой с двумя знаками после запятой, например "1500.00"
+type Amount struct {
+	decimal.Decimal
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// String - сумма с двумя знаками после запятой
+func (a Amount) String() string {
+	return a.StringFixed(moneyDecimalPlaces)
+}
+
+// amountOf - стоимость времени spent по почасовой ставке rate, с точностью до копеек
+func amountOf(rate decimal.Decimal, spent time.Duration) Amount {
+	sum := moneySum{}
+	sum.add("", rate, spent)
+	return Amount{sum.money()[""]}
+}
+
+// moneySum - точная сумма ставка × наносекунды по валютам, в деньги переводится в конце,
+// чтобы округление не накапливалось
+type moneySum map[string]decimal.Decimal
+
+func (m moneySum) add(currency string, rate decimal.Decimal, spent time.Duration) {
+	m[currency] = m[currency].Add(rate.Mul(decimal.NewFromInt(int64(spent))))
+}
+
+func (m moneySum) money() Money {
+	hour := decimal.NewFromInt(int64(time.Hour))
+	money := make(Money, len(m))
+	for currency, sum := range m {
+		money[currency] = sum.DivRound(hour, moneyDecimalPlaces)
+	}
+	return money
+}
+
+// Ставка интервала работы
+type entryRate struct {
+	ProjectId int32               // проект задачи, 0 - задача без проекта
+	Billable  bool                // время оплачивается клиентом
+	Currency  string              // валюта ставки
+	Rate      decimal.NullDecimal // почасовая ставка, не задана - время без суммы
+}
+
+// rateBook - задачи, проекты и пользователи интервалов для расчета ставок
+type rateBook struct {
+	currency string
+	tasks    map[int32]*Task
+	projects map[int32]*Project
+	users    map[int32]*User
+}
+
+// newRateBook - загрузить задачи, проекты и пользователей интервалов
+func (s *TimeTrackingService) newRateBook(ctx context.Context, entries []*TimeEntry) (*rateBook, error) {
+	book := &rateBook{
+		currency: s.currency,
+		tasks:    map[int32]*Task{},
+		projects: map[int32]*Project{},
+		users:    map[int32]*User{},
+	}
+	if len(entries) == 0 {
+		return book, nil
+	}
+
+	taskIds := make([]int32, 0, len(entries))
+	userIds := make([]int32, 0, len(entries))
+	for _, entry := range entries {
+		taskIds = append(taskIds, entry.TaskId)
+		userIds = append(userIds, entry.UserId)
+	}
+
+	tasks, err := s.FindTasksByFilter(ctx, In("id", taskIds...), nil, Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	var projectIds []int32
+	for _, task := range tasks {
+		book.tasks[task.Id] = task
+		if task.ProjectId != 0 {
+			projectIds = append(projectIds, task.ProjectId)
+		}
+	}
+
+	if len(projectIds) > 0 {
+		projects, err := s.FindProjectsByFilter(ctx, In("id", projectIds...), nil, Pagination{})
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			book.projects[project.Id] = project
+		}
+	}
+
+	users, err := s.FindUsersByFilter(ctx, In("id", userIds...), nil, Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		book.users[user.Id] = user
+	}
+
+	return book, nil
+}
+
+// rate - ставка интервала: ставка задачи, иначе проекта, иначе пользователя.
+// Время оплачивается, если оплачиваются и задача, и ее проект
+func (b *rateBook) rate(entry *TimeEntry) entryRate {
+	rate := entryRate{Currency: b.currency}
+
+	task, ok := b.tasks[entry.TaskId]
+	if !ok {
+		return rate
+	}
+	rate.ProjectId = task.ProjectId
+	rate.Billable = task.Billable
+	rate.Rate = task.HourlyRate
+
+	if project, ok := b.projects[task.ProjectId]; ok {
+		rate.Billable = rate.Billable && project.Billable
+		if project.Currency != "" {
+			rate.Currency = project.Currency
+		}
+		if !rate.Rate.Valid {
+			rate.Rate = project.HourlyRate
+		}
+	}
+
+	if user, ok := b.users[entry.UserId]; ok && !rate.Rate.Valid {
+		rate.Rate = user.HourlyRate
+	}
+
+	return rate
+}
@@ -0,0 +1,99 @@
+package timetracking
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRateBookRate(t *testing.T) {
+	rate := func(s string) decimal.NullDecimal {
+		return decimal.NewNullDecimal(decimal.RequireFromString(s))
+	}
+
+	book := &rateBook{
+		currency: "RUB",
+		tasks: map[int32]*Task{
+			1: {Id: 1, ProjectId: 10, HourlyRate: rate("3000"), Billable: true},
+			2: {Id: 2, ProjectId: 10, Billable: true},
+			3: {Id: 3, Billable: true},
+			4: {Id: 4, ProjectId: 20, Billable: true},
+			5: {Id: 5, ProjectId: 10, Billable: false},
+			6: {Id: 6, ProjectId: 99, Billable: true},
+		},
+		projects: map[int32]*Project{
+			10: {Id: 10, HourlyRate: rate("2000"), Currency: "EUR", Billable: true},
+			20: {Id: 20, Billable: false},
+		},
+		users: map[int32]*User{
+			100: {Id: 100, HourlyRate: rate("1000")},
+			200: {Id: 200},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		entry  TimeEntry
+		want   entryRate
+		noRate bool
+	}{
+		{
+			name:  "task rate first",
+			entry: TimeEntry{TaskId: 1, UserId: 100},
+			want:  entryRate{ProjectId: 10, Billable: true, Currency: "EUR", Rate: rate("3000")},
+		},
+		{
+			name:  "project rate without task rate",
+			entry: TimeEntry{TaskId: 2, UserId: 100},
+			want:  entryRate{ProjectId: 10, Billable: true, Currency: "EUR", Rate: rate("2000")},
+		},
+		{
+			name:  "user rate without project",
+			entry: TimeEntry{TaskId: 3, UserId: 100},
+			want:  entryRate{Billable: true, Currency: "RUB", Rate: rate("1000")},
+		},
+		{
+			name:  "user rate when project has none, project not billable",
+			entry: TimeEntry{TaskId: 4, UserId: 100},
+			want:  entryRate{ProjectId: 20, Billable: false, Currency: "RUB", Rate: rate("1000")},
+		},
+		{
+			name:  "task not billable",
+			entry: TimeEntry{TaskId: 5, UserId: 200},
+			want:  entryRate{ProjectId: 10, Billable: false, Currency: "EUR", Rate: rate("2000")},
+		},
+		{
+			name:   "no rate",
+			entry:  TimeEntry{TaskId: 3, UserId: 200},
+			want:   entryRate{Billable: true, Currency: "RUB"},
+			noRate: true,
+		},
+		{
+			name:   "unknown project",
+			entry:  TimeEntry{TaskId: 6, UserId: 200},
+			want:   entryRate{ProjectId: 99, Billable: true, Currency: "RUB"},
+			noRate: true,
+		},
+		{
+			name:   "unknown task",
+			entry:  TimeEntry{TaskId: 7, UserId: 100},
+			want:   entryRate{Currency: "RUB"},
+			noRate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := book.rate(&tt.entry)
+			if got.ProjectId != tt.want.ProjectId || got.Billable != tt.want.Billable || got.Currency != tt.want.Currency {
+				t.Errorf("rate() = %+v, want %+v", got, tt.want)
+			}
+			if got.Rate.Valid == tt.noRate {
+				t.Fatalf("rate() rate valid = %v, want %v", got.Rate.Valid, !tt.noRate)
+			}
+			if !tt.noRate && !got.Rate.Decimal.Equal(tt.want.Rate.Decimal) {
+				t.Errorf("rate() rate = %s, want %s", got.Rate.Decimal, tt.want.Rate.Decimal)
+			}
+		})
+	}
+}
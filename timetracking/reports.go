@@ -0,0 +1,388 @@
+package timetracking
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// ReportPeriod - длина периода отчета
+type ReportPeriod string
+
+const (
+	ReportDay   ReportPeriod = "day"   // сутки
+	ReportWeek  ReportPeriod = "week"  // неделя с понедельника
+	ReportMonth ReportPeriod = "month" // календарный месяц
+)
+
+// ReportGroupBy - группировка времени внутри периода
+type ReportGroupBy string
+
+const (
+	ReportByTask    ReportGroupBy = "task"    // по задачам
+	ReportByUser    ReportGroupBy = "user"    // по пользователям
+	ReportByProject ReportGroupBy = "project" // по проектам, задачи без проекта - в строке с id 0
+)
+
+// maxReportPeriods - ограничение количества периодов в отчете
+const maxReportPeriods = 1000
+
+// Параметры отчета
+type ReportParams struct {
+	From     time.Time      // первый день отчета
+	To       time.Time      // последний день отчета, включительно
+	Period   ReportPeriod   // длина периода
+	GroupBy  ReportGroupBy  // группировка внутри периода
+	Location *time.Location // часовой пояс границ дней, nil - часовой пояс сервиса
+
+	PasportSeries string // только время пользователя, пусто - всех пользователей
+	PasportNumber string
+	TaskId        int32 // только время по задаче, 0 - по всем задачам
+	ProjectId     int32 // только время по задачам проекта, 0 - по всем проектам
+}
+
+// validate - проверка параметров
+func (p *ReportParams) validate() error {
+	switch p.Period {
+	case ReportDay, ReportWeek, ReportMonth:
+	default:
+		return &InvalidError{fmt.Sprintf("unknown period %q, expected day, week or month", p.Period)}
+	}
+
+	switch p.GroupBy {
+	case ReportByTask, ReportByUser, ReportByProject:
+	default:
+		return &InvalidError{fmt.Sprintf("unknown groupBy %q, expected task, user or project", p.GroupBy)}
+	}
+
+	if p.From.IsZero() || p.To.IsZero() {
+		return &InvalidError{"periodFrom and periodTo are required"}
+	}
+	if p.From.After(p.To) {
+		return &InvalidError{"periodFrom is after periodTo"}
+	}
+
+	if (p.PasportSeries == "") != (p.PasportNumber == "") {
+		return &InvalidError{"invalid passport"}
+	}
+
+	return nil
+}
+
+// Затраченное время и его стоимость
+type ReportTime struct {
+	Seconds         int64  `json:"seconds"`                            // затраченное время в секундах
+	Duration        string `json:"duration"`                           // затраченное время, например "1h 05m 09s"
+	BillableSeconds int64  `json:"billableSeconds"`                    // оплачиваемое время в секундах
+	Amount          Money  `json:"amount" swaggertype:"object,string"` // стоимость оплачиваемого времени по валютам
+
+	spent    time.Duration
+	billable time.Duration
+	amount   moneySum
+}
+
+// add - добавить время интервала со ставкой rate
+func (t *ReportTime) add(spent time.Duration, rate entryRate) {
+	t.spent += spent
+	if !rate.Billable {
+		return
+	}
+
+	t.billable += spent
+	if rate.Rate.Valid {
+		if t.amount == nil {
+			t.amount = moneySum{}
+		}
+		t.amount.add(rate.Currency, rate.Rate.Decimal, spent)
+	}
+}
+
+// fill - заполнить поля ответа по накопленному времени
+func (t *ReportTime) fill() {
+	t.Seconds = int64(t.spent / time.Second)
+	t.Duration = formatDuration(t.spent)
+	t.BillableSeconds = int64(t.billable / time.Second)
+	t.Amount = t.amount.money()
+}
+
+// Время задачи, пользователя или проекта
+type ReportRow struct {
+	Id   int32  `json:"id"`   // идентификатор задачи, пользователя или проекта
+	Name string `json:"name"` // название задачи или проекта, ФИО пользователя
+	ReportTime
+}
+
+// Время за период отчета
+type ReportBucket struct {
+	Start time.Time `json:"start"` // начало периода
+	End   time.Time `json:"end"`   // конец периода, не включительно
+	ReportTime
+	Rows []*ReportRow `json:"rows"` // время по задачам, пользователям или проектам, по убыванию
+
+	rows map[int32]*ReportRow
+}
+
+// Отчет о затраченном времени
+type Report struct {
+	From    time.Time       `json:"from"`    // начало отчета
+	To      time.Time       `json:"to"`      // конец отчета, не включительно
+	Period  ReportPeriod    `json:"period"`  // длина периода
+	GroupBy ReportGroupBy   `json:"groupBy"` // группировка
+	Buckets []*ReportBucket `json:"buckets"` // все периоды по порядку, включая периоды без времени
+	Totals  []*ReportRow    `json:"totals"`  // итоги по задачам, пользователям или проектам за весь отчет, по убыванию
+	ReportTime
+}
+
+// periodStart - начало периода, в котором находится t
+func periodStart(t time.Time, period ReportPeriod) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case ReportWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case ReportMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// nextPeriod - начало следующего периода
+func nextPeriod(start time.Time, period ReportPeriod) time.Time {
+	switch period {
+	case ReportWeek:
+		return start.AddDate(0, 0, 7)
+	case ReportMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// addRow - добавить время со ставкой rate к строке id
+func addRow(rows map[int32]*ReportRow, id int32, spent time.Duration, rate entryRate) {
+	row, ok := rows[id]
+	if !ok {
+		row = &ReportRow{Id: id}
+		rows[id] = row
+	}
+	row.add(spent, rate)
+}
+
+// sortedRows - строки по убыванию времени, затем по идентификатору, с заполненными названиями
+func sortedRows(rows map[int32]*ReportRow, names map[int32]string) []*ReportRow {
+	result := make([]*ReportRow, 0, len(rows))
+	for _, row := range rows {
+		row.Name = names[row.Id]
+		row.fill()
+		result = append(result, row)
+	}
+
+	slices.SortFunc(result, func(a, b *ReportRow) int {
+		if c := cmp.Compare(b.spent, a.spent); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return result
+}
+
+// Отчет о затраченном времени по дням, неделям или месяцам с группировкой по задачам, пользователям или проектам.
+// Незавершенные интервалы считаются до текущего момента, паузы не учитываются.
+// Стоимость оплачиваемого времени считается по ставке задачи, иначе проекта, иначе пользователя
+func (s *TimeTrackingService) Report(ctx context.Context, params ReportParams) (*Report, error) {
+	const op = "TimeTrackingService: Report"
+
+	Logger.Debug(op, slog.Any("params", params))
+
+	if err := params.validate(); err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	location := params.Location
+	if location == nil {
+		location = s.workdayLocation
+	}
+
+	// Границы отчета - целые дни в часовом поясе отчета
+	from := time.Date(params.From.Year(), params.From.Month(), params.From.Day(), 0, 0, 0, 0, location)
+	to := time.Date(params.To.Year(), params.To.Month(), params.To.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+
+	// Периоды отчета, первый и последний обрезаются границами отчета
+	var buckets []*ReportBucket
+	for start := periodStart(from, params.Period); start.Before(to); start = nextPeriod(start, params.Period) {
+		if len(buckets) == maxReportPeriods {
+			Logger.Info(op+" failed", slog.String("error", "too many periods"))
+			return nil, &InvalidError{fmt.Sprintf("report has more than %d periods", maxReportPeriods)}
+		}
+		bucket := &ReportBucket{Start: start, End: nextPeriod(start, params.Period), rows: map[int32]*ReportRow{}}
+		if bucket.Start.Before(from) {
+			bucket.Start = from
+		}
+		if bucket.End.After(to) {
+			bucket.End = to
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	// Интервалы работы, пересекающиеся с отчетом
+	conditions := []Filter{overlapsFilter(from.UTC(), to.UTC())}
+	if params.PasportSeries != "" {
+		user, err := s.FindUserByPassport(ctx, params.PasportSeries, params.PasportNumber)
+		if err != nil {
+			return nil, processStorageError(op, err, false)
+		}
+		conditions = append(conditions, Eq("user_id", user.Id))
+	}
+	if params.TaskId != 0 {
+		conditions = append(conditions, Eq("task_id", params.TaskId))
+	}
+
+	// Только задачи проекта, в проекте без задач интервалов нет
+	noTasks := false
+	if params.ProjectId != 0 {
+		project, err := s.FindProjectById(ctx, params.ProjectId)
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return nil, processStorageError(op, err, false)
+		}
+		tasks, err := s.FindTasksByFilter(ctx, Eq("project_id", project.Id), nil, Pagination{})
+		if err != nil {
+			return nil, processStorageError(op, err, false)
+		}
+		taskIds := make([]int32, len(tasks))
+		for i, task := range tasks {
+			taskIds[i] = task.Id
+		}
+		conditions = append(conditions, In("task_id", taskIds...))
+		noTasks = len(taskIds) == 0
+	}
+
+	var entries []*TimeEntry
+	if !noTasks {
+		var err error
+		entries, err = s.FindTimeEntriesByFilter(ctx, And(conditions...), nil, Pagination{})
+		if err != nil {
+			return nil, processStorageError(op, err, false)
+		}
+	}
+
+	// Ставки и проекты задач интервалов
+	book, err := s.newRateBook(ctx, entries)
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	// Время интервалов по периодам
+	now := time.Now().UTC()
+	totals := map[int32]*ReportRow{}
+	var total ReportTime
+	for _, entry := range entries {
+		rate := book.rate(entry)
+
+		id := entry.TaskId
+		switch params.GroupBy {
+		case ReportByUser:
+			id = entry.UserId
+		case ReportByProject:
+			id = rate.ProjectId
+		}
+
+		end := now
+		if entry.EndedAt != nil {
+			end = *entry.EndedAt
+		}
+
+		first := sort.Search(len(buckets), func(i int) bool {
+			return buckets[i].End.After(entry.StartedAt)
+		})
+		for _, bucket := range buckets[first:] {
+			if !bucket.Start.Before(end) {
+				break
+			}
+
+			spent := entry.DurationIn(bucket.Start, bucket.End, now)
+			if spent == 0 {
+				continue
+			}
+
+			bucket.add(spent, rate)
+			addRow(bucket.rows, id, spent, rate)
+			addRow(totals, id, spent, rate)
+			total.add(spent, rate)
+		}
+	}
+
+	// Названия задач или ФИО пользователей
+	ids := make([]int32, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	names, err := s.reportNames(ctx, params.GroupBy, ids)
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	for _, bucket := range buckets {
+		bucket.fill()
+		bucket.Rows = sortedRows(bucket.rows, names)
+	}
+
+	total.fill()
+	report := &Report{
+		From:       from,
+		To:         to,
+		Period:     params.Period,
+		GroupBy:    params.GroupBy,
+		Buckets:    buckets,
+		Totals:     sortedRows(totals, names),
+		ReportTime: total,
+	}
+
+	Logger.Debug("TimeTrackingService: Report report built", slog.Int("periods", len(buckets)), slog.Int("entries", len(entries)))
+	return report, nil
+}
+
+// reportNames - названия задач, проектов или ФИО пользователей по идентификаторам
+func (s *TimeTrackingService) reportNames(ctx context.Context, groupBy ReportGroupBy, ids []int32) (map[int32]string, error) {
+	names := map[int32]string{}
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	if groupBy == ReportByProject {
+		projects, err := s.FindProjectsByFilter(ctx, In("id", ids...), nil, Pagination{})
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			names[project.Id] = project.Name
+		}
+		return names, nil
+	}
+
+	if groupBy == ReportByUser {
+		users, err := s.FindUsersByFilter(ctx, In("id", ids...), nil, Pagination{})
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			names[user.Id] = strings.Join(strings.Fields(user.Surname+" "+user.Name+" "+user.Patronymic), " ")
+		}
+		return names, nil
+	}
+
+	tasks, err := s.FindTasksByFilter(ctx, In("id", ids...), nil, Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		names[task.Id] = task.Title
+	}
+	return names, nil
+}
@@ -1,14 +1,17 @@
 package timetracking
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
-	"sort"
-	"strings"
+	"slices"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	. "timetracking/storage"
 )
 
@@ -16,69 +19,99 @@ var Logger = slog.Default()
 
 // Структура пользователя
 type User struct {
-	Id            int32  `json:"-"`
-	PasportSeries string `json:"-"`                    // серия паспорта
-	PasportNumber string `json:"-"`                    // номер паспорта
-	Surname       string `json:"surname"`              // фамилия
-	Name          string `json:"name"`                 // имя
-	Patronymic    string `json:"patronymic,omitempty"` // отчество
-	Address       string `json:"address"`              // адрес
-}
-
-func NewUser(data map[string]any) *User {
-	return &User{
-		Id:            get[int32](data, "id"),
-		PasportSeries: get[string](data, "pasport_series"),
-		PasportNumber: get[string](data, "pasport_number"),
-		Surname:       get[string](data, "surname"),
-		Name:          get[string](data, "name"),
-		Patronymic:    get[string](data, "patronymic"),
-		Address:       get[string](data, "address"),
-	}
+	Id            int32  `json:"-" db:"id"`
+	PasportSeries string `json:"-" db:"pasport_series"`                        // серия паспорта
+	PasportNumber string `json:"-" db:"pasport_number"`                        // номер паспорта
+	Surname       string `json:"surname" db:"surname,null"`                    // фамилия
+	Name          string `json:"name" db:"name,null"`                          // имя
+	Patronymic    string `json:"patronymic,omitempty" db:"patronymic,null"`    // отчество
+	Address       string `json:"address" db:"address,null"`                    // адрес
+	WorkdayEnd    string `json:"workdayEnd,omitempty" db:"workday_end,null"`   // конец рабочего дня "ЧЧ:ММ"
+	TimerPolicy   string `json:"timerPolicy,omitempty" db:"timer_policy,null"` // политика одного таймера, пусто - общая
+
+	HourlyRate decimal.NullDecimal `json:"hourlyRate" db:"hourly_rate" swaggertype:"string"` // почасовая ставка, null - не задана
+
+	Created time.Time `json:"created" db:"created"` // дата создания
 }
 
 type Task struct {
-	Id          int32     `json:"-"`
-	Title       string    `json:"title"`       // название
-	Description string    `json:"description"` // описание
-	PeriodFrom  time.Time `json:"periodFrom"`  // начало периода
-	PeriodTo    time.Time `json:"periodTo"`    // конец периода
-
-	UserId   int32         `json:"userId"`   // идентификатор пользователя
-	Cost     time.Duration `json:"cost"`     // потраченное время
-	WorkFrom time.Time     `json:"WorkFrom"` // время начала работы
-}
+	Id          int32     `json:"id" db:"id"`
+	Title       string    `json:"title" db:"title,null"`                    // название
+	Description string    `json:"description" db:"description,null"`        // описание
+	PeriodFrom  time.Time `json:"periodFrom" db:"period_from,null"`         // начало периода
+	PeriodTo    time.Time `json:"periodTo" db:"period_to,null"`             // конец периода
+	ProjectId   int32     `json:"projectId,omitempty" db:"project_id,null"` // проект, 0 - задача без проекта
 
-func NewTask(data map[string]any) *Task {
-	return &Task{
-		Id:          get[int32](data, "id"),
-		Title:       get[string](data, "title"),
-		Description: get[string](data, "description"),
-		PeriodFrom:  get[time.Time](data, "period_from"),
-		PeriodTo:    get[time.Time](data, "period_to"),
+	HourlyRate decimal.NullDecimal `json:"hourlyRate" db:"hourly_rate" swaggertype:"string"` // почасовая ставка, null - ставка проекта или пользователя
+	Billable   bool                `json:"billable" db:"billable"`                           // время оплачивается клиентом
 
-		UserId:   get[int32](data, "user_id"),
-		Cost:     time.Duration(get[int64](data, "cost")),
-		WorkFrom: get[time.Time](data, "work_from"),
-	}
+	Cost        time.Duration `json:"cost" db:"cost,null" swaggertype:"integer"`                   // потраченное время всех пользователей
+	Estimate    time.Duration `json:"estimate,omitempty" db:"estimate,null" swaggertype:"integer"` // оценка трудоемкости, 0 - не задана
+	CompletedAt *time.Time    `json:"completedAt,omitempty" db:"completed_at"`                     // завершение задачи, nil - не завершена
+
+	Status TaskStatus `json:"status" db:"status"` // статус задачи: todo, in_progress, paused, done, cancelled
+
+	Timer TimerState `json:"timer,omitempty"` // состояние отсчета времени: running - время идет хотя бы у одного пользователя
+
+	Created time.Time `json:"created" db:"created"` // дата создания
 }
 
 // Сервис
 type TimeTrackingService struct {
 	storage Storage // интерфейс подключения к базе данных
+
+	requestTimeout time.Duration // ограничение времени обработки http-запроса
+
+	maxTimerDuration time.Duration  // максимальная длительность интервала работы, 0 - без ограничения
+	workdayLocation  *time.Location // часовой пояс конца рабочего дня пользователей
+
+	timerPolicy TimerPolicy // общая политика одного таймера пользователя
+
+	currency string // валюта ставок по умолчанию
+}
+
+// Option - настройка сервиса
+type Option func(*TimeTrackingService)
+
+// WithRequestTimeout - ограничение времени обработки http-запроса, 0 - без ограничения
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(s *TimeTrackingService) {
+		s.requestTimeout = timeout
+	}
 }
 
 // Конструктор
-func NewTimeTrackingService(storage Storage) *TimeTrackingService {
-	return &TimeTrackingService{
-		storage: storage,
+func NewTimeTrackingService(storage Storage, options ...Option) *TimeTrackingService {
+	s := &TimeTrackingService{
+		storage:         storage,
+		workdayLocation: time.Local,
+		timerPolicy:     TimerPolicyReject,
+		currency:        DefaultCurrency,
+	}
+
+	for _, option := range options {
+		option(s)
 	}
+
+	return s
 }
 
 func processStorageError(op string, err error, needLog bool) error {
 	if err == nil {
 		return nil
 	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		if needLog {
+			slog.Info(op + " timeout")
+		}
+		return errors.Join(&TimeoutError{}, err)
+	}
+	if errors.As(err, new(*ScanError)) {
+		if needLog {
+			slog.Info(op + " scan failed")
+		}
+		return errors.Join(&InternalError{}, err)
+	}
 	if errors.Is(err, sql.ErrNoRows) {
 		if needLog {
 			slog.Info(op + " not found")
@@ -92,22 +125,51 @@ func processStorageError(op string, err error, needLog bool) error {
 	return errors.Join(&StorageError{}, err)
 }
 
+// withTx - выполнить fn в транзакции хранилища.
+// fn получает копию сервиса, все операции которой идут через транзакцию
+func (s *TimeTrackingService) withTx(ctx context.Context, op string, fn func(tx *TimeTrackingService) error) error {
+	var fnErr error
+	err := s.storage.WithTx(ctx, func(txStorage Storage) error {
+		tx := *s
+		tx.storage = txStorage
+
+		fnErr = fn(&tx)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+
+	return processStorageError(op, err, true)
+}
+
 // Методы
 
+// stableSort - сортировка с идентификатором в конце, чтобы порядок страниц
+// не зависел от базы данных при равных значениях
+func stableSort(sort []Sort) []Sort {
+	for _, s := range sort {
+		if s.Field == "id" {
+			return sort
+		}
+	}
+	return append(slices.Clip(sort), Sort{Field: "id"})
+}
+
 // Находит пользователя по паспорту
-func (s *TimeTrackingService) FindUserByPassport(pasportSeries, pasportNumber string) (*User, error) {
+func (s *TimeTrackingService) FindUserByPassport(ctx context.Context, pasportSeries, pasportNumber string) (*User, error) {
 	slog.Debug("TimeTrackingService: FindUserByPassport", slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber))
 
 	if pasportSeries == "" || pasportNumber == "" {
 		return nil, &InvalidError{"pasportSeries or pasportNumber is empty"}
 	}
 
-	filter := map[string]any{
+	filter := Match{
 		"pasport_series": pasportSeries,
 		"pasport_number": pasportNumber,
 	}
 
-	user, err := s.FindUsersByFilter(filter, 1, 0)
+	user, err := s.FindUsersByFilter(ctx, filter, nil, Pagination{Limit: 1})
 	if err != nil {
 		slog.Info("TimeTrackingService: FindUserByPassport failed", slog.String("error", err.Error()))
 		return nil, err
@@ -123,26 +185,26 @@ func (s *TimeTrackingService) FindUserByPassport(pasportSeries, pasportNumber st
 
 // Находит пользователей по фильтру с пагинацией, возвращает список пользователей
 // Если не находит записей возвращает ErrNoRows
-func (s *TimeTrackingService) FindUsersByFilter(filter map[string]any, limit, offset int) ([]*User, error) {
+func (s *TimeTrackingService) FindUsersByFilter(ctx context.Context, filter Filter, sort []Sort, page Pagination) ([]*User, error) {
 	const op = "TimeTrackingService: FindUsersByFilter"
 
-	Logger.Debug(op, slog.Any("filter", filter), slog.Int("limit", limit), slog.Int("offset", offset))
+	Logger.Debug(op, slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
 
-	// Получение пользователей по фильтру с пагинацией
-	reader, err := s.storage.Select(UserCollection, filter, limit, offset)
+	filter, sort, err := paginate(filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получение пользователей по фильтру с сортировкой и пагинацией
+	reader, err := s.storage.Select(ctx, UserCollection, filter, sort, page.Limit, page.Offset)
 	if err != nil {
 		return nil, processStorageError(op, err, true)
 	}
 
 	// Чтение пользователей
-	var users []*User
-	for reader.Next() {
-		record, err := reader.Read()
-		if err := processStorageError(op, err, true); err != nil {
-			return nil, err
-		}
-
-		users = append(users, NewUser(record.Fields))
+	users, err := ReadAll[User](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
 	}
 
 	Logger.Debug("TimeTrackingService: FindUsersByFilter users found", slog.Int("count", len(users)))
@@ -151,253 +213,620 @@ func (s *TimeTrackingService) FindUsersByFilter(filter map[string]any, limit, of
 
 // Находит задач по фильтру с пагинацией, возвращает список задач
 // Если не находит записей возвращает ErrNoRows
-func (s *TimeTrackingService) FindTasksByFilter(filter map[string]any, limit, offset int) ([]*Task, error) {
+func (s *TimeTrackingService) FindTasksByFilter(ctx context.Context, filter Filter, sort []Sort, page Pagination) ([]*Task, error) {
 	const op = "TimeTrackingService: FindTasksByFilter"
 
-	Logger.Debug(op, slog.Any("filter", filter), slog.Int("limit", limit), slog.Int("offset", offset))
+	Logger.Debug(op, slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	filter, sort, err := paginate(filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
 
-	// Получение задач по фильтру с пагинацией
-	reader, err := s.storage.Select(TaskCollection, filter, limit, offset)
+	// Получение задач по фильтру с сортировкой и пагинацией
+	reader, err := s.storage.Select(ctx, TaskCollection, filter, sort, page.Limit, page.Offset)
 	if err != nil {
 		return nil, processStorageError(op, err, true)
 	}
 
 	// Чтение задач
-	var tasks []*Task
-	for reader.Next() {
-		record, err := reader.Read()
-		if err := processStorageError(op, err, true); err != nil {
-			return nil, err
-		}
-
-		tasks = append(tasks, NewTask(record.Fields))
+	tasks, err := ReadAll[Task](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
 	}
 
 	Logger.Debug("TimeTrackingService: FindTasksByFilter tasks found", slog.Int("count", len(tasks)))
 	return tasks, nil
 }
 
-// Вычисляет стоимость задачи по идентификатору пользователя
-func (s *TimeTrackingService) CalculateCostByUser(pasportSeries, pasportNumber string, begin, end time.Time) ([]string, error) {
+// CountUsersByFilter - количество пользователей по фильтру
+func (s *TimeTrackingService) CountUsersByFilter(ctx context.Context, filter Filter) (int64, error) {
+	const op = "TimeTrackingService: CountUsersByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter))
+
+	count, err := s.storage.Count(ctx, UserCollection, filter)
+	if err != nil {
+		return 0, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: CountUsersByFilter users counted", slog.Int64("count", count))
+	return count, nil
+}
+
+// CountTasksByFilter - количество задач по фильтру
+func (s *TimeTrackingService) CountTasksByFilter(ctx context.Context, filter Filter) (int64, error) {
+	const op = "TimeTrackingService: CountTasksByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter))
+
+	count, err := s.storage.Count(ctx, TaskCollection, filter)
+	if err != nil {
+		return 0, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: CountTasksByFilter tasks counted", slog.Int64("count", count))
+	return count, nil
+}
+
+// Вычисляет время работы пользователя над задачами внутри периода [begin, end],
+// задачи упорядочены по убыванию затраченного времени
+func (s *TimeTrackingService) CalculateCostByUser(ctx context.Context, pasportSeries, pasportNumber string, begin, end time.Time) ([]*TaskCost, error) {
 	const op = "TimeTrackingService: CalculateCostByUser"
 
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber))
 
 	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
+	user, err := s.FindUserByPassport(ctx, pasportSeries, pasportNumber)
 	if err != nil {
 		return nil, processStorageError(op, err, false)
 	}
 
 	Logger.Debug("TimeTrackingService: CalculateCostByUser user found", slog.Int("user", int(user.Id)))
 
-	// Получение задач пользователя
-	filter := map[string]any{
-		"user_id": user.Id,
-	}
-	reader, err := s.storage.Select(TaskCollection, filter, 0, 0)
+	// Получение интервалов работы пользователя, пересекающихся с запрошенным периодом
+	filter := And(
+		Eq("user_id", user.Id),
+		overlapsFilter(begin, end),
+	)
+	entries, err := s.FindTimeEntriesByFilter(ctx, filter, nil, Pagination{})
 	if err != nil {
-		return nil, processStorageError(op, err, true)
+		return nil, processStorageError(op, err, false)
 	}
 
-	// Подсчет затраченного времени
-	var costs []string
-	for reader.Next() {
-		record, err := reader.Read()
-		if err := processStorageError(op, err, true); err != nil {
-			return nil, err
+	// Подсчет затраченного времени внутри периода по задачам
+	now := time.Now().UTC()
+	var taskIds []int32
+	costs := map[int32]*TaskCost{}
+	for _, entry := range entries {
+		cost, ok := costs[entry.TaskId]
+		if !ok {
+			cost = &TaskCost{TaskId: entry.TaskId, Timer: TimerStopped}
+			costs[entry.TaskId] = cost
+			taskIds = append(taskIds, entry.TaskId)
 		}
+		cost.spent += entry.DurationIn(begin, end, now)
+		cost.Timer = mergeTimerState(cost.Timer, entry.State())
+		cost.Running = cost.Timer == TimerRunning
+	}
 
-		periodFrom := get[time.Time](record.Fields, "period_from")
-		periodTo := get[time.Time](record.Fields, "period_to")
-
-		if periodTo.Before(begin) || periodFrom.After(end) {
-			continue
-		}
+	// Названия задач
+	tasks, err := s.FindTasksByFilter(ctx, In("id", taskIds...), nil, Pagination{})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+	for _, task := range tasks {
+		costs[task.Id].Title = task.Title
+	}
 
-		costs = append(costs, fmt.Sprintf("%d-%v", record.Id, time.Duration(get[int64](record.Fields, "cost")).Truncate(time.Second)))
+	result := make([]*TaskCost, 0, len(taskIds))
+	for _, taskId := range taskIds {
+		cost := costs[taskId]
+		cost.Seconds = int64(cost.spent / time.Second)
+		cost.Duration = formatDuration(cost.spent)
+		result = append(result, cost)
 	}
 
-	sort.Slice(costs, func(i, j int) bool {
-		costI := strings.Split(costs[i], "-")
-		costJ := strings.Split(costs[j], "-")
-		return costI[1] > costJ[1]
+	slices.SortFunc(result, func(a, b *TaskCost) int {
+		if c := cmp.Compare(b.spent, a.spent); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.TaskId, b.TaskId)
 	})
 
-	Logger.Debug("TimeTrackingService: CalculateCostByUser cost calculated", slog.Int("userId", int(user.Id)), slog.Any("costs", costs))
-	return costs, nil
+	Logger.Debug("TimeTrackingService: CalculateCostByUser cost calculated", slog.Int("userId", int(user.Id)), slog.Int("tasks", len(result)))
+	return result, nil
 }
 
 // Запуск задачи для пользователя
-func (s *TimeTrackingService) BeginTaskForUser(pasportSeries, pasportNumber string, taskId int32) error {
+func (s *TimeTrackingService) BeginTaskForUser(ctx context.Context, pasportSeries, pasportNumber string, taskId int32, note string) error {
 	const op = "TimeTrackingService: BeginTaskForUser"
 
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
 
-	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
-	if err != nil {
-		return processStorageError(op, err, false)
-	}
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
 
-	Logger.Debug(op+": user found", slog.Int("user", int(user.Id)))
+		Logger.Debug(op+": user found", slog.Int("user", int(user.Id)))
 
-	// Поиск задачи по идентификатору
-	filter := map[string]any{
-		"id": taskId,
-	}
-	task, err := s.FindTasksByFilter(filter, 1, 0)
-	if err != nil {
-		return processStorageError(op, err, false)
-	}
+		// Поиск задачи по идентификатору, задача блокируется до конца транзакции
+		filter := Match{
+			"id": taskId,
+		}
+		task, err := tx.FindTasksByFilter(ctx, filter, nil, Pagination{Limit: 1})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		if len(task) == 0 {
+			return processStorageError(op, &NotFoundError{"task not found"}, true)
+		}
 
-	Logger.Debug(op+": task found", slog.Int("task", int(task[0].Id)))
+		Logger.Debug(op+": task found", slog.Int("task", int(task[0].Id)))
 
-	if task[0].WorkFrom != (time.Time{}) {
-		return processStorageError(op, errors.New("task already started"), true)
-	}
+		// По завершенной или отмененной задаче время не ведется
+		if err := checkTaskOpen(task[0]); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
 
-	// Начало задачи
-	updateData := map[string]any{
-		"work_from": time.Now().UTC(),
-		"user_id":   user.Id,
-	}
-	err = s.storage.Update(TaskCollection, filter, updateData)
-	if err != nil {
-		return processStorageError(op, err, true)
-	}
+		// Отсчет времени ведется отдельно для каждого пользователя
+		entry, err := tx.openTimeEntry(ctx, task[0].Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
 
-	Logger.Debug(op+": task started", slog.Int("userId", int(user.Id)), slog.Int("task", int(task[0].Id)))
-	return nil
+		if entry != nil && entry.State() == TimerPaused {
+			return stateError(op, "task is paused, resume it")
+		}
+		if entry != nil {
+			return stateError(op, "task already started")
+		}
+
+		// Не больше одного идущего таймера пользователя
+		err = tx.ensureSingleTimer(ctx, op, user, task[0].Id)
+		if err != nil {
+			return err
+		}
+
+		// Пользователь, начавший задачу, становится назначенным на нее
+		err = tx.assign(ctx, task[0].Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		// Открытие интервала работы
+		entryData := map[string]any{
+			"task_id":      task[0].Id,
+			"user_id":      user.Id,
+			"started_at":   time.Now().UTC(),
+			"auto_stopped": false,
+		}
+		if note != "" {
+			entryData["note"] = note
+		}
+		_, err = tx.storage.Insert(ctx, TimeEntryCollection, entryData)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		// Начало работы переводит задачу в работу
+		if task[0].Status != TaskInProgress {
+			err = tx.setTaskStatus(ctx, op, task[0], TaskInProgress, "time tracking started")
+			if err != nil {
+				return err
+			}
+		}
+
+		Logger.Debug(op+": task started", slog.Int("userId", int(user.Id)), slog.Int("task", int(task[0].Id)))
+		return nil
+	})
 }
 
 // Завершение задачи для пользователя
-func (s *TimeTrackingService) EndTaskForUser(pasportSeries, pasportNumber string, taskId int32) error {
+func (s *TimeTrackingService) EndTaskForUser(ctx context.Context, pasportSeries, pasportNumber string, taskId int32, note string) error {
 	const op = "TimeTrackingService: EndTaskForUser"
 
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
 
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		Logger.Debug("TimeTrackingService: EndTaskForUser user found", slog.Int("user", int(user.Id)))
+
+		// Поиск задачи по идентификатору, задача блокируется до конца транзакции
+		filter := Match{
+			"id": taskId,
+		}
+		task, err := tx.FindTasksByFilter(ctx, filter, nil, Pagination{Limit: 1})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		if len(task) == 0 {
+			return processStorageError(op, &NotFoundError{"task not found"}, true)
+		}
+
+		Logger.Debug("TimeTrackingService: EndTaskForUser task found", slog.Int("task", int(task[0].Id)))
+
+		// Незавершенный интервал работы пользователя по задаче
+		entry, err := tx.openTimeEntry(ctx, task[0].Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		if entry == nil {
+			return stateError(op, "task not started")
+		}
+
+		// Закрытие интервала работы и учет времени в задаче
+		err = tx.stopTimeEntry(ctx, entry, time.Now().UTC(), false, note)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: EndTaskForUser task ended", slog.Int("userId", int(user.Id)), slog.Int("task", int(task[0].Id)))
+		return nil
+	})
+}
+
+// Приостановка задачи для пользователя, интервал работы остается открытым
+func (s *TimeTrackingService) PauseTaskForUser(ctx context.Context, pasportSeries, pasportNumber string, taskId int32) error {
+	const op = "TimeTrackingService: PauseTaskForUser"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		entry, err := tx.findOpenTimeEntry(ctx, op, pasportSeries, pasportNumber, taskId)
+		if err != nil {
+			return err
+		}
+
+		if entry.State() == TimerPaused {
+			return stateError(op, "task already paused")
+		}
+
+		// Начало паузы
+		pauseData := map[string]any{
+			"entry_id":   entry.Id,
+			"started_at": time.Now().UTC(),
+		}
+		_, err = tx.storage.Insert(ctx, TimeEntryPauseCollection, pauseData)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: PauseTaskForUser task paused", slog.Int("entryId", int(entry.Id)))
+		return nil
+	})
+}
+
+// Возобновление приостановленной задачи для пользователя
+func (s *TimeTrackingService) ResumeTaskForUser(ctx context.Context, pasportSeries, pasportNumber string, taskId int32) error {
+	const op = "TimeTrackingService: ResumeTaskForUser"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Int("taskId", int(taskId)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		entry, err := tx.findOpenTimeEntry(ctx, op, pasportSeries, pasportNumber, taskId)
+		if err != nil {
+			return err
+		}
+
+		pause := entry.openPause()
+		if pause == nil {
+			return stateError(op, "task not paused")
+		}
+
+		// Не больше одного идущего таймера пользователя
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+		err = tx.ensureSingleTimer(ctx, op, user, entry.TaskId)
+		if err != nil {
+			return err
+		}
+
+		// Конец паузы
+		err = tx.storage.Update(ctx, TimeEntryPauseCollection, Match{"id": pause.Id}, map[string]any{"ended_at": time.Now().UTC()})
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: ResumeTaskForUser task resumed", slog.Int("entryId", int(entry.Id)))
+		return nil
+	})
+}
+
+// stateError - действие противоречит состоянию отсчета времени задачи
+func stateError(op, msg string) error {
+	Logger.Info(op+" failed", slog.String("error", msg))
+	return &ConflictError{msg}
+}
+
+// findOpenTimeEntry - незавершенный интервал работы пользователя по задаче, задача блокируется до конца транзакции
+func (s *TimeTrackingService) findOpenTimeEntry(ctx context.Context, op, pasportSeries, pasportNumber string, taskId int32) (*TimeEntry, error) {
 	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
+	user, err := s.FindUserByPassport(ctx, pasportSeries, pasportNumber)
 	if err != nil {
-		return processStorageError(op, err, false)
+		return nil, processStorageError(op, err, false)
 	}
 
-	Logger.Debug("TimeTrackingService: EndTaskForUser user found", slog.Int("user", int(user.Id)))
-
 	// Поиск задачи по идентификатору
-	filter := map[string]any{
-		"id": taskId,
-	}
-	task, err := s.FindTasksByFilter(filter, 1, 0)
+	task, err := s.FindTaskById(ctx, taskId)
 	if err != nil {
-		return processStorageError(op, err, false)
+		return nil, err
 	}
 
-	if len(task) == 0 {
-		return processStorageError(op, &NotFoundError{"task not found"}, true)
+	entry, err := s.openTimeEntry(ctx, task.Id, user.Id)
+	if err != nil {
+		return nil, processStorageError(op, err, false)
 	}
 
-	Logger.Debug("TimeTrackingService: EndTaskForUser task found", slog.Int("task", int(task[0].Id)))
-
-	if task[0].WorkFrom == (time.Time{}) {
-		return processStorageError(op, errors.New("task not started"), true)
+	if entry == nil {
+		return nil, stateError(op, "task not started")
 	}
 
-	// Конец задачи
-	updateData := map[string]any{
-		"cost":      task[0].Cost + time.Now().UTC().Sub(task[0].WorkFrom),
-		"work_from": nil,
-	}
-	err = s.storage.Update(TaskCollection, filter, updateData)
-	if err != nil {
-		return processStorageError(op, err, true)
-	}
-
-	Logger.Debug("TimeTrackingService: EndTaskForUser task ended", slog.Int("userId", int(user.Id)), slog.Int("task", int(task[0].Id)))
-	return nil
+	return entry, nil
 }
 
-// Удаление пользователя
-func (s *TimeTrackingService) DeleteUser(pasportSeries, pasportNumber string) error {
+// Удаление пользователя вместе с его интервалами работы и назначениями
+func (s *TimeTrackingService) DeleteUser(ctx context.Context, pasportSeries, pasportNumber string) error {
 	const op = "TimeTrackingService: DeleteUser"
 
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber))
 
-	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
-	if err != nil {
-		return processStorageError(op, err, false)
-	}
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
 
-	Logger.Debug("TimeTrackingService: DeleteUser user found", slog.Int("user", int(user.Id)))
+		Logger.Debug("TimeTrackingService: DeleteUser user found", slog.Int("user", int(user.Id)))
 
-	// Удаление пользователя
-	err = s.storage.Delete(UserCollection, user.Id)
-	if err != nil {
-		return processStorageError(op, err, true)
-	}
+		// Интервалы работы пользователя, нельзя удалить пользователя с идущими таймерами или оплаченным временем
+		entries, err := tx.FindTimeEntriesByFilter(ctx, Eq("user_id", user.Id), nil, Pagination{})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+		for _, entry := range entries {
+			if entry.EndedAt == nil {
+				Logger.Info(op+" failed", slog.String("error", "user has a started task"), slog.Int("taskId", int(entry.TaskId)))
+				return &ConflictError{fmt.Sprintf("user has started task %d, end it before deleting", entry.TaskId)}
+			}
+			if err := checkNotInvoiced(entry); err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				return err
+			}
+		}
+
+		// Удаление интервалов вместе с паузами, их время вычитается из общего времени задач
+		for _, entry := range entries {
+			err = tx.deleteTimeEntry(ctx, entry)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+			err = tx.addTaskCost(ctx, entry.TaskId, -entry.DurationIn(entry.StartedAt, *entry.EndedAt, *entry.EndedAt))
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// Снятие пользователя с задач
+		assignments, err := tx.findAssignments(ctx, Eq("user_id", user.Id))
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+		for _, assignment := range assignments {
+			err = tx.storage.Delete(ctx, TaskAssignmentCollection, assignment.Id)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// Удаление пользователя
+		err = tx.storage.Delete(ctx, UserCollection, user.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: DeleteUser user deleted", slog.Int("userId", int(user.Id)), slog.Int("entries", len(entries)))
+		return nil
+	})
+}
 
-	Logger.Debug("TimeTrackingService: DeleteUser user deleted", slog.Int("userId", int(user.Id)))
+// userInfoFields - поля пользователя, которые можно обновить, и нужно ли проверять, что значение - строка или null.
+// Значения workdayEnd, timerPolicy и hourlyRate проверяются при обновлении
+var userInfoFields = map[string]bool{
+	"surname":     true,
+	"name":        true,
+	"patronymic":  true,
+	"address":     true,
+	"workdayEnd":  false,
+	"timerPolicy": false,
+	"hourlyRate":  false,
+}
+
+// checkUserInfo - обновляются только известные поля, текстовые поля - строки или null
+func checkUserInfo(info map[string]any) error {
+	if len(info) == 0 {
+		return &InvalidError{"nothing to update"}
+	}
+	for key, value := range info {
+		text, ok := userInfoFields[key]
+		if !ok {
+			return &InvalidError{fmt.Sprintf("unknown field %q", key)}
+		}
+		if _, isString := value.(string); text && value != nil && !isString {
+			return &InvalidError{fmt.Sprintf("%s must be a string", key)}
+		}
+	}
 	return nil
 }
 
 // Обновление информации о пользователе
-func (s *TimeTrackingService) UpdateInfoUser(pasportSeries, pasportNumber string, info map[string]any) error {
+func (s *TimeTrackingService) UpdateInfoUser(ctx context.Context, pasportSeries, pasportNumber string, info map[string]any) error {
 	const op = "TimeTrackingService: UpdateInfoUser"
 
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Any("info", info))
 
-	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
-	if err != nil {
-		return processStorageError(op, err, false)
+	if err := checkUserInfo(info); err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
 	}
 
-	Logger.Debug("TimeTrackingService: UpdateInfoUser user found", slog.Int("user", int(user.Id)))
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
 
-	// Обновление информации о пользователе
-	filter := map[string]any{
-		"id": user.Id,
-	}
-	err = s.storage.Update(UserCollection, filter, info)
-	if err != nil {
-		return processStorageError(op, err, true)
-	}
+		Logger.Debug("TimeTrackingService: UpdateInfoUser user found", slog.Int("user", int(user.Id)))
+
+		// Конец рабочего дня "ЧЧ:ММ", пустое значение или null - без ограничения
+		if workdayEnd, ok := info["workdayEnd"]; ok {
+			delete(info, "workdayEnd")
+			switch v := workdayEnd.(type) {
+			case nil:
+				info["workday_end"] = nil
+			case string:
+				if v == "" {
+					info["workday_end"] = nil
+					break
+				}
+				clock, err := time.Parse(workdayEndLayout, v)
+				if err != nil {
+					Logger.Info(op+" failed", slog.String("error", "invalid workdayEnd"))
+					return &InvalidError{"workdayEnd must be in HH:MM format"}
+				}
+				info["workday_end"] = clock.Format(workdayEndLayout)
+			default:
+				Logger.Info(op+" failed", slog.String("error", "invalid workdayEnd"))
+				return &InvalidError{"workdayEnd must be in HH:MM format"}
+			}
+		}
 
-	Logger.Debug("TimeTrackingService: UpdateInfoUser user updated", slog.Int("userId", int(user.Id)))
+		// Политика одного таймера, пустое значение или null - общая настройка сервиса
+		if timerPolicy, ok := info["timerPolicy"]; ok {
+			delete(info, "timerPolicy")
+			v, isString := timerPolicy.(string)
+			switch {
+			case timerPolicy == nil || isString && v == "":
+				info["timer_policy"] = nil
+			case isString:
+				policy, err := ParseTimerPolicy(v)
+				if err != nil {
+					Logger.Info(op+" failed", slog.String("error", err.Error()))
+					return err
+				}
+				info["timer_policy"] = string(policy)
+			default:
+				Logger.Info(op+" failed", slog.String("error", "invalid timerPolicy"))
+				return &InvalidError{"timerPolicy must be a string"}
+			}
+		}
 
-	return nil
+		// Почасовая ставка строкой, пустое значение или null - ставка не задана
+		if hourlyRate, ok := info["hourlyRate"]; ok {
+			delete(info, "hourlyRate")
+			v, isString := hourlyRate.(string)
+			if hourlyRate != nil && !isString {
+				Logger.Info(op+" failed", slog.String("error", "invalid hourlyRate"))
+				return &InvalidError{"hourlyRate must be a string like \"1500.00\""}
+			}
+			info["hourly_rate"], err = rateField(v)
+			if err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				return err
+			}
+		}
+
+		// Обновление информации о пользователе
+		filter := Match{
+			"id": user.Id,
+		}
+		err = tx.storage.Update(ctx, UserCollection, filter, info)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: UpdateInfoUser user updated", slog.Int("userId", int(user.Id)))
+
+		return nil
+	})
 }
 
 // Создание пользователя
-func (s *TimeTrackingService) CreateUser(pasportSeries, pasportNumber string) (int32, error) {
+func (s *TimeTrackingService) CreateUser(ctx context.Context, pasportSeries, pasportNumber string) (int32, error) {
 	const op = "TimeTrackingService: CreateUser"
 	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber))
 
-	// Поиск пользователя по паспорту
-	user, err := s.FindUserByPassport(pasportSeries, pasportNumber)
-	if err != nil && !errors.Is(err, &NotFoundError{}) {
-		return 0, processStorageError(op, err, false)
-	}
+	var newId int32
+	err := s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil && !errors.Is(err, &NotFoundError{}) {
+			return processStorageError(op, err, false)
+		}
 
-	// Пользователь уже существует, возвращаем его идентификатор
-	if user != nil {
-		Logger.Debug("TimeTrackingService: CreateUser user found", slog.Int("user", int(user.Id)))
-		return user.Id, nil
-	}
+		// Пользователь уже существует, возвращаем его идентификатор
+		if user != nil {
+			Logger.Debug("TimeTrackingService: CreateUser user found", slog.Int("user", int(user.Id)))
+			newId = user.Id
+			return nil
+		}
 
-	// Создание пользователя
-	userData := map[string]any{
-		"pasport_series": pasportSeries,
-		"pasport_number": pasportNumber,
-	}
+		// Создание пользователя
+		userData := map[string]any{
+			"pasport_series": pasportSeries,
+			"pasport_number": pasportNumber,
+		}
+
+		// Пользователя с тем же паспортом мог создать параллельный запрос
+		newId, err = tx.storage.Insert(ctx, UserCollection, userData)
+		if errors.Is(err, ErrDuplicate) {
+			Logger.Info(op+" failed", slog.String("error", "user is already created"))
+			return &ConflictError{"user with this passport is already created, retry the request to get its id"}
+		}
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
 
-	newId, err := s.storage.Insert(UserCollection, userData)
+		Logger.Debug("TimeTrackingService: CreateUser user created", slog.Int("userId", int(newId)))
+		return nil
+	})
 	if err != nil {
-		return 0, processStorageError(op, err, true)
+		return 0, err
 	}
 
-	Logger.Debug("TimeTrackingService: CreateUser user created", slog.Int("userId", int(newId)))
 	return newId, nil
 }
+
+// Статистика пула соединений хранилища
+func (s *TimeTrackingService) PoolStats(ctx context.Context) (*PoolStats, error) {
+	const op = "TimeTrackingService: PoolStats"
+
+	Logger.Debug(op)
+
+	provider, ok := s.storage.(StatsProvider)
+	if !ok {
+		Logger.Info(op+" failed", slog.String("error", "storage has no connection pool"))
+		return nil, &NotFoundError{"storage has no connection pool"}
+	}
+
+	stats := provider.Stats()
+
+	Logger.Debug(op+": stats", slog.Any("stats", stats))
+	return &stats, nil
+}
@@ -0,0 +1,209 @@
+package timetracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	. "timetracking/storage"
+)
+
+// TaskStatus - статус задачи, не зависит от состояния отсчета времени (timer)
+type TaskStatus string
+
+const (
+	TaskTodo       TaskStatus = "todo"        // работа не начата
+	TaskInProgress TaskStatus = "in_progress" // задача в работе
+	TaskPaused     TaskStatus = "paused"      // работа отложена
+	TaskDone       TaskStatus = "done"        // задача завершена
+	TaskCancelled  TaskStatus = "cancelled"   // задача отменена
+)
+
+// taskTransitions - допустимые переходы между статусами. Начало работы по задаче переводит ее в in_progress,
+// остановка последнего таймера по задаче - из in_progress в paused
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	TaskTodo:       {TaskInProgress, TaskDone, TaskCancelled},
+	TaskInProgress: {TaskPaused, TaskDone, TaskCancelled},
+	TaskPaused:     {TaskInProgress, TaskDone, TaskCancelled},
+	TaskDone:       {TaskInProgress},
+	TaskCancelled:  {TaskTodo},
+}
+
+// maxStatusNoteLength - ограничение длины комментария к смене статуса, совпадает с размером колонки
+const maxStatusNoteLength = 500
+
+// ParseTaskStatus - статус задачи по названию
+func ParseTaskStatus(s string) (TaskStatus, error) {
+	status := TaskStatus(s)
+	if _, ok := taskTransitions[status]; !ok {
+		return "", &InvalidError{fmt.Sprintf("unknown status %q, expected todo, in_progress, paused, done or cancelled", s)}
+	}
+	return status, nil
+}
+
+// Closed - по завершенной или отмененной задаче время не ведется
+func (s TaskStatus) Closed() bool {
+	return s == TaskDone || s == TaskCancelled
+}
+
+// Смена статуса задачи
+type TaskStatusChange struct {
+	Id         int32      `json:"id" db:"id"`
+	TaskId     int32      `json:"taskId" db:"task_id"`                        // идентификатор задачи
+	FromStatus TaskStatus `json:"fromStatus,omitempty" db:"from_status,null"` // прежний статус, пусто - задача создана
+	ToStatus   TaskStatus `json:"toStatus" db:"to_status"`                    // новый статус
+	Note       string     `json:"note,omitempty" db:"note,null"`              // комментарий
+
+	Created time.Time `json:"created" db:"created"` // время смены статуса
+}
+
+// Новый статус задачи
+type TaskStatusData struct {
+	Status TaskStatus `json:"status"` // новый статус
+	Note   string     `json:"note"`   // комментарий, например причина отмены
+}
+
+// checkTaskOpen - по задаче можно вести время: задача не завершена и не отменена
+func checkTaskOpen(task *Task) error {
+	if task.Status.Closed() {
+		return &ConflictError{fmt.Sprintf("task %d is %s, reopen it to track time", task.Id, task.Status)}
+	}
+	return nil
+}
+
+// addStatusChange - запись смены статуса в историю
+func (s *TimeTrackingService) addStatusChange(ctx context.Context, taskId int32, from, to TaskStatus, note string) error {
+	change := map[string]any{
+		"task_id":   taskId,
+		"to_status": string(to),
+	}
+	if from != "" {
+		change["from_status"] = string(from)
+	}
+	if note != "" {
+		change["note"] = note
+	}
+	_, err := s.storage.Insert(ctx, TaskStatusHistoryCollection, change)
+	return err
+}
+
+// pauseStoppedTask - перевод задачи из in_progress в paused, если по ней не осталось незавершенных интервалов работы.
+// Задачи в других статусах не меняются
+func (s *TimeTrackingService) pauseStoppedTask(ctx context.Context, taskId int32) error {
+	count, err := s.storage.Count(ctx, TimeEntryCollection, And(Eq("task_id", taskId), IsNull("ended_at")))
+	if err != nil || count > 0 {
+		return err
+	}
+
+	reader, err := s.storage.Select(ctx, TaskCollection, Match{"id": taskId}, nil, 1, 0)
+	if err != nil {
+		return err
+	}
+	tasks, err := ReadAll[Task](reader)
+	if err != nil || len(tasks) == 0 || tasks[0].Status != TaskInProgress {
+		return err
+	}
+
+	err = s.storage.Update(ctx, TaskCollection, Match{"id": taskId}, map[string]any{"status": string(TaskPaused)})
+	if err != nil {
+		return err
+	}
+
+	Logger.Debug("TimeTrackingService: task paused after time tracking stopped", slog.Int("taskId", int(taskId)))
+	return s.addStatusChange(ctx, taskId, TaskInProgress, TaskPaused, "time tracking stopped")
+}
+
+// setTaskStatus - перевод задачи в статус to с записью в историю. Отложить, завершить или отменить
+// можно только задачу без незавершенных интервалов работы
+func (s *TimeTrackingService) setTaskStatus(ctx context.Context, op string, task *Task, to TaskStatus, note string) error {
+	if !slices.Contains(taskTransitions[task.Status], to) {
+		Logger.Info(op+" failed", slog.String("error", "invalid status transition"), slog.String("from", string(task.Status)), slog.String("to", string(to)))
+		return &ConflictError{fmt.Sprintf("task %d can't change status from %s to %s", task.Id, task.Status, to)}
+	}
+
+	if to == TaskPaused || to.Closed() {
+		if err := s.checkTaskStopped(ctx, op, task.Id, "changing its status to "+string(to)); err != nil {
+			return err
+		}
+	}
+
+	// Время завершения задачи хранится, пока задача в статусе done
+	update := map[string]any{"status": string(to)}
+	if to == TaskDone {
+		update["completed_at"] = time.Now().UTC()
+	} else if task.Status == TaskDone {
+		update["completed_at"] = nil
+	}
+
+	err := s.storage.Update(ctx, TaskCollection, Match{"id": task.Id}, update)
+	if err != nil {
+		return processStorageError(op, err, true)
+	}
+
+	err = s.addStatusChange(ctx, task.Id, task.Status, to, note)
+	if err != nil {
+		return processStorageError(op, err, true)
+	}
+
+	Logger.Debug(op+": task status changed", slog.Int("taskId", int(task.Id)), slog.String("from", string(task.Status)), slog.String("to", string(to)))
+	task.Status = to
+	return nil
+}
+
+// ChangeTaskStatus - смена статуса задачи по допустимому переходу
+func (s *TimeTrackingService) ChangeTaskStatus(ctx context.Context, id int32, data TaskStatusData) error {
+	const op = "TimeTrackingService: ChangeTaskStatus"
+
+	Logger.Debug(op, slog.Int("id", int(id)), slog.Any("data", data))
+
+	status, err := ParseTaskStatus(string(data.Status))
+	if err == nil && utf8.RuneCountInString(data.Note) > maxStatusNoteLength {
+		err = &InvalidError{fmt.Sprintf("note is longer than %d characters", maxStatusNoteLength)}
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.setTaskStatus(ctx, op, task, status, data.Note)
+	})
+}
+
+// TaskStatusHistory - история смены статусов задачи по порядку
+func (s *TimeTrackingService) TaskStatusHistory(ctx context.Context, id int32) ([]*TaskStatusChange, error) {
+	const op = "TimeTrackingService: TaskStatusHistory"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	task, err := s.FindTaskById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.findStatusChanges(ctx, Eq("task_id", task.Id))
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: TaskStatusHistory history found", slog.Int("taskId", int(task.Id)), slog.Int("count", len(history)))
+	return history, nil
+}
+
+// findStatusChanges - смены статусов по фильтру
+func (s *TimeTrackingService) findStatusChanges(ctx context.Context, filter Filter) ([]*TaskStatusChange, error) {
+	reader, err := s.storage.Select(ctx, TaskStatusHistoryCollection, filter, []Sort{{Field: "id"}}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll[TaskStatusChange](reader)
+}
@@ -0,0 +1,154 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"timetracking/memory"
+	. "timetracking/storage"
+)
+
+// newTestTask - сервис на хранилище в памяти и задача в статусе status
+func newTestTask(t *testing.T, status TaskStatus) (*TimeTrackingService, int32) {
+	t.Helper()
+
+	ctx := context.Background()
+	s := NewTimeTrackingService(memory.NewMemoryStorage())
+
+	title := "Задача"
+	id, err := s.CreateTask(ctx, TaskData{Title: &title})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	if status != TaskTodo {
+		err = s.storage.Update(ctx, TaskCollection, Match{"id": id}, map[string]any{"status": string(status)})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	return s, id
+}
+
+func TestChangeTaskStatus(t *testing.T) {
+	statuses := []TaskStatus{TaskTodo, TaskInProgress, TaskPaused, TaskDone, TaskCancelled}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			allowed := slices.Contains(taskTransitions[from], to)
+
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				ctx := context.Background()
+				s, id := newTestTask(t, from)
+
+				err := s.ChangeTaskStatus(ctx, id, TaskStatusData{Status: to, Note: "note"})
+				if !allowed {
+					if !errors.Is(err, &ConflictError{}) {
+						t.Fatalf("ChangeTaskStatus() error = %v, want ConflictError", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ChangeTaskStatus() error = %v", err)
+				}
+
+				task, err := s.FindTaskById(ctx, id)
+				if err != nil {
+					t.Fatalf("FindTaskById() error = %v", err)
+				}
+				if task.Status != to {
+					t.Errorf("status = %s, want %s", task.Status, to)
+				}
+				if (task.CompletedAt != nil) != (to == TaskDone) {
+					t.Errorf("completedAt = %v with status %s", task.CompletedAt, to)
+				}
+
+				history, err := s.TaskStatusHistory(ctx, id)
+				if err != nil {
+					t.Fatalf("TaskStatusHistory() error = %v", err)
+				}
+				last := history[len(history)-1]
+				if last.FromStatus != from || last.ToStatus != to || last.Note != "note" {
+					t.Errorf("last history record = %+v, want %s -> %s", last, from, to)
+				}
+			})
+		}
+	}
+}
+
+func TestChangeTaskStatusInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data TaskStatusData
+	}{
+		{name: "unknown status", data: TaskStatusData{Status: "started"}},
+		{name: "empty status", data: TaskStatusData{}},
+		{name: "long note", data: TaskStatusData{Status: TaskDone, Note: string(make([]rune, maxStatusNoteLength+1))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, id := newTestTask(t, TaskTodo)
+
+			err := s.ChangeTaskStatus(context.Background(), id, tt.data)
+			if !errors.Is(err, &InvalidError{}) {
+				t.Errorf("ChangeTaskStatus() error = %v, want InvalidError", err)
+			}
+		})
+	}
+}
+
+func TestTaskStatusWithTimer(t *testing.T) {
+	ctx := context.Background()
+	s, id := newTestTask(t, TaskTodo)
+
+	if _, err := s.CreateUser(ctx, "1234", "567890"); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	status := func() TaskStatus {
+		t.Helper()
+		task, err := s.FindTaskById(ctx, id)
+		if err != nil {
+			t.Fatalf("FindTaskById() error = %v", err)
+		}
+		return task.Status
+	}
+
+	// Начало работы переводит задачу в работу
+	if err := s.BeginTaskForUser(ctx, "1234", "567890", id, ""); err != nil {
+		t.Fatalf("BeginTaskForUser() error = %v", err)
+	}
+	if got := status(); got != TaskInProgress {
+		t.Fatalf("status after begin = %s, want %s", got, TaskInProgress)
+	}
+
+	// С идущим таймером задачу нельзя завершить
+	for _, to := range []TaskStatus{TaskPaused, TaskDone, TaskCancelled} {
+		err := s.ChangeTaskStatus(ctx, id, TaskStatusData{Status: to})
+		if !errors.Is(err, &ConflictError{}) {
+			t.Errorf("ChangeTaskStatus(%s) with running timer error = %v, want ConflictError", to, err)
+		}
+	}
+
+	// Остановка последнего таймера откладывает задачу
+	if err := s.EndTaskForUser(ctx, "1234", "567890", id, ""); err != nil {
+		t.Fatalf("EndTaskForUser() error = %v", err)
+	}
+	if got := status(); got != TaskPaused {
+		t.Fatalf("status after end = %s, want %s", got, TaskPaused)
+	}
+
+	if err := s.ChangeTaskStatus(ctx, id, TaskStatusData{Status: TaskDone}); err != nil {
+		t.Fatalf("ChangeTaskStatus(done) error = %v", err)
+	}
+
+	// По завершенной задаче время не ведется
+	err := s.BeginTaskForUser(ctx, "1234", "567890", id, "")
+	if !errors.Is(err, &ConflictError{}) {
+		t.Errorf("BeginTaskForUser() on done task error = %v, want ConflictError", err)
+	}
+}
@@ -1,32 +1,230 @@
 package timetracking
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	. "timetracking/storage"
 )
 
+// filterOperators - суффиксы полей фильтра и соответствующие операторы
+var filterOperators = map[string]Operator{
+	"eq":     OpEq,
+	"neq":    OpNeq,
+	"gt":     OpGt,
+	"gte":    OpGte,
+	"lt":     OpLt,
+	"lte":    OpLte,
+	"in":     OpIn,
+	"like":   OpLike,
+	"isnull": OpIsNull,
+}
+
 // parseFilter - парсинг фильтра
-func parseFilter(filterS string) map[string]any {
-	filter := map[string]any{}
+// Условия разделяются "&&" (в запросе "%26%26"), условие - "поле=значение" или "поле__оператор=значение",
+// операторы: eq, neq, gt, gte, lt, lte, in (значения через запятую), like, isnull (true/false)
+func parseFilter(filterS string) (Filter, error) {
+	conditions := []Filter{}
 	if len(filterS) != 0 {
 		pairs := strings.Split(filterS, "%26%26")
 		for _, pair := range pairs {
-			parts := strings.Split(pair, "=")
+			parts := strings.SplitN(pair, "=", 2)
 			if len(parts) != 2 {
 				continue
 			}
-			filter[parts[0]] = parts[1]
+
+			field, value := parts[0], parts[1]
+			operator := OpEq
+			if i := strings.LastIndex(field, "__"); i > 0 {
+				op, ok := filterOperators[field[i+2:]]
+				if !ok {
+					return nil, &InvalidError{"unknown filter operator " + field[i+2:]}
+				}
+				field, operator = field[:i], op
+			}
+
+			switch operator {
+			case OpIn:
+				conditions = append(conditions, In(field, strings.Split(value, ",")...))
+			case OpIsNull:
+				isNull, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, &InvalidError{"invalid isnull value for " + field}
+				}
+				conditions = append(conditions, Condition{Field: field, Operator: OpIsNull, Value: isNull})
+			default:
+				conditions = append(conditions, Condition{Field: field, Operator: operator, Value: value})
+			}
+		}
+	}
+
+	return And(conditions...), nil
+}
+
+// Поля, по которым разрешена сортировка списков
+var (
+	userSortFields = []string{"id", "surname", "name", "patronymic", "address", "created"}
+	taskSortFields = []string{"id", "title", "period_from", "period_to", "cost", "estimate", "status", "completed_at", "project_id", "created"}
+
+	projectSortFields = []string{"id", "name", "code", "client", "period_from", "period_to", "created"}
+
+	invoiceSortFields = []string{"id", "number", "client", "project_id", "period_from", "period_to", "created"}
+
+	timeEntrySortFields = []string{"id", "task_id", "user_id", "started_at", "ended_at", "created"}
+)
+
+// parseSort - парсинг сортировки "поле1,-поле2", "-" - по убыванию.
+// Поля не из списка allowed отклоняются
+func parseSort(sortS string, allowed []string) ([]Sort, error) {
+	sort := []Sort{}
+	if len(sortS) == 0 {
+		return sort, nil
+	}
+
+	for _, field := range strings.Split(sortS, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		if !slices.Contains(allowed, field) {
+			return nil, &InvalidError{"unknown sort field " + field}
 		}
+
+		sort = append(sort, Sort{Field: field, Desc: desc})
+	}
+
+	return sort, nil
+}
+
+// parseId - идентификатор записи из параметра запроса
+func parseId(idS string) (int32, error) {
+	id, err := strconv.ParseInt(idS, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, &InvalidError{"invalid id"}
+	}
+	return int32(id), nil
+}
+
+// parseUserTask - серия и номер паспорта и идентификатор задачи из тела запроса
+// {"pasportNumber": "1234 567890", "taskId": 1}
+func parseUserTask(r *http.Request) ([]string, int32, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var data struct {
+		PasportSeriesNumber string `json:"pasportNumber"`
+		TaskId              int32  `json:"taskId"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, 0, &InvalidError{err.Error()}
+	}
+
+	seriesNumber := strings.Split(data.PasportSeriesNumber, " ")
+	if len(seriesNumber) != 2 || seriesNumber[0] == "" || seriesNumber[1] == "" {
+		return nil, 0, &InvalidError{"invalid passport"}
 	}
 
-	return filter
+	return seriesNumber, data.TaskId, nil
 }
 
-// sendResponseOrError - обработка ошибок
+// parseReportParams - параметры отчета из запроса, по умолчанию по дням и по задачам
+func parseReportParams(r *http.Request) (ReportParams, error) {
+	query := r.URL.Query()
+
+	params := ReportParams{
+		Period:        ReportPeriod(query.Get("period")),
+		GroupBy:       ReportGroupBy(query.Get("groupBy")),
+		PasportSeries: query.Get("pasportSeries"),
+		PasportNumber: query.Get("pasportNumber"),
+	}
+	if params.Period == "" {
+		params.Period = ReportDay
+	}
+	if params.GroupBy == "" {
+		params.GroupBy = ReportByTask
+	}
+
+	var err error
+	if v := query.Get("periodFrom"); v != "" {
+		if params.From, err = time.Parse(time.DateOnly, v); err != nil {
+			return params, &InvalidError{"invalid periodFrom, expected YYYY-MM-DD"}
+		}
+	}
+	if v := query.Get("periodTo"); v != "" {
+		if params.To, err = time.Parse(time.DateOnly, v); err != nil {
+			return params, &InvalidError{"invalid periodTo, expected YYYY-MM-DD"}
+		}
+	}
+
+	if v := query.Get("taskId"); v != "" {
+		if params.TaskId, err = parseId(v); err != nil {
+			return params, err
+		}
+	}
+
+	if v := query.Get("projectId"); v != "" {
+		if params.ProjectId, err = parseId(v); err != nil {
+			return params, err
+		}
+	}
+
+	if v := query.Get("tz"); v != "" {
+		if params.Location, err = time.LoadLocation(v); err != nil {
+			return params, &InvalidError{"unknown time zone " + v}
+		}
+	}
+
+	return params, nil
+}
+
+// parseBoolParam - необязательный логический параметр запроса, nil - параметр не задан
+func parseBoolParam(query url.Values, name string) (*bool, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, &InvalidError{"invalid " + name}
+	}
+	return &b, nil
+}
+
+// sendTable - ответ таблицей CSV или XLSX, которую пишет write.
+// Ошибка до первой строки таблицы возвращается обычным ответом с ошибкой
+func sendTable(op string, w http.ResponseWriter, format ExportFormat, name string, write func(tableWriter) error) {
+	tw, err := newTableWriter(w, format, name)
+	if err == nil {
+		err = write(tw)
+		if err == nil {
+			err = tw.Close()
+		}
+	}
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		sendResponseOrError(op, err, w, nil)
+		return
+	}
+
+	slog.Debug(op+" success", slog.String("format", string(format)))
+}
+
+// sendResponseOrError - обработка ошибок, код ответа задается до записи тела
 // Если ошибки нет - возвращаем 200 и тело запроса или OK
 // Если внутренняя ошибка - возвращаем 500 и текст ошибки
+// Если истекло время обработки - возвращаем 504 и текст ошибки
+// Если действие противоречит текущему состоянию - возвращаем 409 и текст ошибки
 // Если ошибка - возвращаем 400 и текст ошибки
 func sendResponseOrError(op string, err error, w http.ResponseWriter, body []byte, attr ...any) {
 	if err == nil {
@@ -35,25 +233,29 @@ func sendResponseOrError(op string, err error, w http.ResponseWriter, body []byt
 			body = []byte("OK")
 		}
 		w.Write(body)
-		w.WriteHeader(http.StatusOK)
 		return
 	}
 
 	slog.Info(op+" failed", append(attr, slog.String("error", err.Error()))...)
 
 	if errors.Is(err, &InternalError{}) {
-		w.Write([]byte(err.Error()))
 		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
 		return
 	}
 
-	w.Write([]byte(err.Error()))
-	w.WriteHeader(http.StatusBadRequest)
-}
+	if errors.Is(err, &TimeoutError{}) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte(err.Error()))
+		return
+	}
 
-func get[T any](fields map[string]any, name string) T {
-	if v, ok := fields[name].(T); ok {
-		return v
+	if errors.Is(err, &ConflictError{}) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
 	}
-	return *new(T)
+
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte(err.Error()))
 }
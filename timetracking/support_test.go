@@ -0,0 +1,82 @@
+package timetracking
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "timetracking/storage"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    Filter
+		invalid bool
+	}{
+		{
+			name:   "empty",
+			filter: "",
+			want:   And([]Filter{}...),
+		},
+		{
+			name:   "equal by default",
+			filter: "title=Задача 1",
+			want:   And(Eq("title", "Задача 1")),
+		},
+		{
+			name:   "operators",
+			filter: "cost__gte=10%26%26title__like=Задача%%26%26id__neq=3",
+			want:   And(Gte("cost", "10"), Like("title", "Задача%"), Neq("id", "3")),
+		},
+		{
+			name:   "in",
+			filter: "id__in=1,2,3",
+			want:   And(In("id", "1", "2", "3")),
+		},
+		{
+			name:   "isnull",
+			filter: "ended_at__isnull=true%26%26project_id__isnull=false",
+			want:   And(IsNull("ended_at"), IsNotNull("project_id")),
+		},
+		{
+			name:   "value with equal sign",
+			filter: "note=a=b",
+			want:   And(Eq("note", "a=b")),
+		},
+		{
+			name:   "condition without value is skipped",
+			filter: "title%26%26id=1",
+			want:   And(Eq("id", "1")),
+		},
+		{
+			name:    "unknown operator",
+			filter:  "id__between=1",
+			invalid: true,
+		},
+		{
+			name:    "invalid isnull",
+			filter:  "ended_at__isnull=maybe",
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilter(tt.filter)
+			if tt.invalid {
+				if !errors.Is(err, &InvalidError{}) {
+					t.Fatalf("parseFilter(%q) error = %v, want InvalidError", tt.filter, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilter(%q) error = %v", tt.filter, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilter(%q) = %#v, want %#v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
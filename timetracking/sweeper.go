@@ -0,0 +1,139 @@
+package timetracking
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// workdayEndLayout - формат конца рабочего дня пользователя
+const workdayEndLayout = "15:04"
+
+// WithMaxTimerDuration - максимальная длительность интервала работы, после которой
+// отсчет времени останавливается автоматически, 0 - без ограничения
+func WithMaxTimerDuration(duration time.Duration) Option {
+	return func(s *TimeTrackingService) {
+		s.maxTimerDuration = duration
+	}
+}
+
+// WithWorkdayLocation - часовой пояс, в котором задан конец рабочего дня пользователей
+func WithWorkdayLocation(location *time.Location) Option {
+	return func(s *TimeTrackingService) {
+		s.workdayLocation = location
+	}
+}
+
+// autoStopAt - момент автоматической остановки интервала: не позже maxTimerDuration
+// от начала и не позже ближайшего после начала конца рабочего дня пользователя.
+// ok == false - интервал не ограничен
+func (s *TimeTrackingService) autoStopAt(entry *TimeEntry, workdayEnd string) (time.Time, bool) {
+	var stopAt time.Time
+
+	if s.maxTimerDuration > 0 {
+		stopAt = entry.StartedAt.Add(s.maxTimerDuration)
+	}
+
+	if workdayEnd != "" {
+		clock, err := time.Parse(workdayEndLayout, workdayEnd)
+		if err == nil {
+			started := entry.StartedAt.In(s.workdayLocation)
+			end := time.Date(started.Year(), started.Month(), started.Day(), clock.Hour(), clock.Minute(), 0, 0, s.workdayLocation)
+			if !end.After(started) {
+				end = end.AddDate(0, 0, 1)
+			}
+			if stopAt.IsZero() || end.Before(stopAt) {
+				stopAt = end
+			}
+		}
+	}
+
+	return stopAt.UTC(), !stopAt.IsZero()
+}
+
+// StopForgottenTimers - остановка интервалов работы, которые идут дольше максимальной длительности
+// или после конца рабочего дня пользователя. Время интервала обрезается моментом остановки,
+// интервал помечается auto_stopped для проверки. Возвращает количество остановленных интервалов
+func (s *TimeTrackingService) StopForgottenTimers(ctx context.Context, now time.Time) (int, error) {
+	const op = "TimeTrackingService: StopForgottenTimers"
+
+	Logger.Debug(op, slog.Time("now", now))
+
+	stopped := 0
+	err := s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Незавершенные интервалы, начатые до now
+		entries, err := tx.FindTimeEntriesByFilter(ctx, And(IsNull("ended_at"), Lt("started_at", now)), nil, Pagination{})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		// Конец рабочего дня пользователей
+		userIds := make([]int32, 0, len(entries))
+		for _, entry := range entries {
+			userIds = append(userIds, entry.UserId)
+		}
+		users, err := tx.FindUsersByFilter(ctx, In("id", userIds...), nil, Pagination{})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+		workdayEnds := map[int32]string{}
+		for _, user := range users {
+			workdayEnds[user.Id] = user.WorkdayEnd
+		}
+
+		for _, entry := range entries {
+			stopAt, ok := tx.autoStopAt(entry, workdayEnds[entry.UserId])
+			if !ok || stopAt.After(now) {
+				continue
+			}
+
+			err = tx.stopTimeEntry(ctx, entry, stopAt, true, "")
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+
+			Logger.Info(op+": timer stopped", slog.Int("entryId", int(entry.Id)), slog.Int("userId", int(entry.UserId)), slog.Int("taskId", int(entry.TaskId)), slog.Time("stopAt", stopAt))
+			stopped++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return stopped, nil
+}
+
+// RunSweeper - периодическая остановка забытых интервалов работы до отмены ctx
+func (s *TimeTrackingService) RunSweeper(ctx context.Context, interval time.Duration) {
+	const op = "TimeTrackingService: RunSweeper"
+
+	Logger.Debug(op, slog.Duration("interval", interval), slog.Duration("maxTimerDuration", s.maxTimerDuration))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			Logger.Debug(op + " stopped")
+			return
+
+		case <-ticker.C:
+			stopped, err := s.StopForgottenTimers(ctx, time.Now().UTC())
+			if err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				continue
+			}
+			if stopped > 0 {
+				Logger.Info(op+": timers stopped", slog.Int("count", stopped))
+			}
+		}
+	}
+}
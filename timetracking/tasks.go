@@ -0,0 +1,326 @@
+package timetracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "timetracking/storage"
+)
+
+// Ограничения длины полей задачи, совпадают с размерами колонок
+const (
+	maxTaskTitleLength       = 100
+	maxTaskDescriptionLength = 500
+)
+
+// Поля задачи для создания и изменения, nil - поле не задано
+type TaskData struct {
+	Title       *string    `json:"title"`       // название
+	Description *string    `json:"description"` // описание
+	PeriodFrom  *time.Time `json:"periodFrom"`  // начало периода
+	PeriodTo    *time.Time `json:"periodTo"`    // конец периода
+	ProjectId   *int32     `json:"projectId"`   // проект, 0 - задача без проекта
+	HourlyRate  *string    `json:"hourlyRate"`  // почасовая ставка, например "1500.00", пусто - ставка проекта или пользователя
+	Billable    *bool      `json:"billable"`    // время оплачивается клиентом, по умолчанию да
+	Estimate    *string    `json:"estimate"`    // оценка трудоемкости, например "8h" или "1h30m", пусто - оценка не задана
+}
+
+// validate - проверка заданных полей, при создании обязательно название
+func (d *TaskData) validate(create bool) error {
+	if d.Title != nil {
+		title := strings.TrimSpace(*d.Title)
+		if title == "" {
+			return &InvalidError{"title is empty"}
+		}
+		if utf8.RuneCountInString(title) > maxTaskTitleLength {
+			return &InvalidError{fmt.Sprintf("title is longer than %d characters", maxTaskTitleLength)}
+		}
+		d.Title = &title
+	} else if create {
+		return &InvalidError{"title is required"}
+	}
+
+	if d.Description != nil && utf8.RuneCountInString(*d.Description) > maxTaskDescriptionLength {
+		return &InvalidError{fmt.Sprintf("description is longer than %d characters", maxTaskDescriptionLength)}
+	}
+
+	if d.HourlyRate != nil {
+		if _, err := rateField(*d.HourlyRate); err != nil {
+			return err
+		}
+	}
+
+	if d.Estimate != nil {
+		if _, err := estimateField(*d.Estimate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fields - колонки задачи для заданных полей
+func (d *TaskData) fields() map[string]any {
+	fields := map[string]any{}
+	if d.Title != nil {
+		fields["title"] = *d.Title
+	}
+	if d.Description != nil {
+		fields["description"] = *d.Description
+	}
+	if d.PeriodFrom != nil {
+		fields["period_from"] = d.PeriodFrom.UTC()
+	}
+	if d.PeriodTo != nil {
+		fields["period_to"] = d.PeriodTo.UTC()
+	}
+	if d.ProjectId != nil {
+		if *d.ProjectId == 0 {
+			fields["project_id"] = nil
+		} else {
+			fields["project_id"] = *d.ProjectId
+		}
+	}
+	if d.HourlyRate != nil {
+		fields["hourly_rate"], _ = rateField(*d.HourlyRate)
+	}
+	if d.Billable != nil {
+		fields["billable"] = *d.Billable
+	}
+	if d.Estimate != nil {
+		fields["estimate"], _ = estimateField(*d.Estimate)
+	}
+	return fields
+}
+
+// validatePeriod - начало периода не позже конца, незаданные границы не проверяются
+func validatePeriod(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return &InvalidError{"periodFrom is after periodTo"}
+	}
+	return nil
+}
+
+// checkTaskStopped - по задаче нет незавершенных интервалов работы, action - действие для текста ошибки
+func (s *TimeTrackingService) checkTaskStopped(ctx context.Context, op string, taskId int32, action string) error {
+	count, err := s.CountTimeEntriesByFilter(ctx, And(Eq("task_id", taskId), IsNull("ended_at")))
+	if err != nil {
+		return processStorageError(op, err, false)
+	}
+	if count > 0 {
+		Logger.Info(op+" failed", slog.String("error", "task is started"))
+		return &ConflictError{"task is started, end it before " + action}
+	}
+	return nil
+}
+
+// Находит задачу по идентификатору
+func (s *TimeTrackingService) FindTaskById(ctx context.Context, id int32) (*Task, error) {
+	const op = "TimeTrackingService: FindTaskById"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	tasks, err := s.FindTasksByFilter(ctx, Match{"id": id}, nil, Pagination{Limit: 1})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	if len(tasks) == 0 {
+		Logger.Info(op+" failed", slog.String("error", "task not found"))
+		return nil, &NotFoundError{"task not found"}
+	}
+
+	return tasks[0], nil
+}
+
+// Создание задачи
+func (s *TimeTrackingService) CreateTask(ctx context.Context, data TaskData) (int32, error) {
+	const op = "TimeTrackingService: CreateTask"
+
+	Logger.Debug(op, slog.Any("data", data))
+
+	var from, to time.Time
+	if data.PeriodFrom != nil {
+		from = *data.PeriodFrom
+	}
+	if data.PeriodTo != nil {
+		to = *data.PeriodTo
+	}
+
+	err := data.validate(true)
+	if err == nil {
+		err = validatePeriod(from, to)
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	// Задачи можно добавлять только в активный проект
+	if data.ProjectId != nil {
+		if err := s.checkTaskProject(ctx, *data.ProjectId); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return 0, err
+		}
+	}
+
+	taskData := data.fields()
+	taskData["cost"] = int64(0)
+	taskData["status"] = string(TaskTodo)
+	if data.Billable == nil {
+		taskData["billable"] = true
+	}
+
+	// Задача создается вместе с первой записью истории статусов
+	var newId int32
+	err = s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		var err error
+		newId, err = tx.storage.Insert(ctx, TaskCollection, taskData)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		err = tx.addStatusChange(ctx, newId, "", TaskTodo, "")
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: CreateTask task created", slog.Int("taskId", int(newId)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newId, nil
+}
+
+// Изменение задачи, меняются только заданные поля
+func (s *TimeTrackingService) UpdateTask(ctx context.Context, id int32, data TaskData) error {
+	const op = "TimeTrackingService: UpdateTask"
+
+	Logger.Debug(op, slog.Int("id", int(id)), slog.Any("data", data))
+
+	err := data.validate(false)
+	update := data.fields()
+	if err == nil && len(update) == 0 {
+		err = &InvalidError{"nothing to update"}
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Период проверяется вместе с незаданной границей из задачи
+		from, to := task.PeriodFrom, task.PeriodTo
+		if data.PeriodFrom != nil {
+			from = *data.PeriodFrom
+		}
+		if data.PeriodTo != nil {
+			to = *data.PeriodTo
+		}
+		if err := validatePeriod(from, to); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		// Задачи можно переносить только в активный проект
+		if data.ProjectId != nil && *data.ProjectId != task.ProjectId {
+			if err := tx.checkTaskProject(ctx, *data.ProjectId); err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				return err
+			}
+		}
+
+		err = tx.storage.Update(ctx, TaskCollection, Match{"id": id}, update)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: UpdateTask task updated", slog.Int("taskId", int(id)))
+		return nil
+	})
+}
+
+// Удаление задачи вместе с ее интервалами работы и назначениями, начатую задачу удалить нельзя
+func (s *TimeTrackingService) DeleteTask(ctx context.Context, id int32) error {
+	const op = "TimeTrackingService: DeleteTask"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Интервалы работы по задаче
+		entries, err := tx.FindTimeEntriesByFilter(ctx, Eq("task_id", task.Id), nil, Pagination{})
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		for _, entry := range entries {
+			if entry.EndedAt == nil {
+				Logger.Info(op+" failed", slog.String("error", "task is started"))
+				return &ConflictError{"task is started, end it before deleting"}
+			}
+			if err := checkNotInvoiced(entry); err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				return err
+			}
+		}
+
+		for _, entry := range entries {
+			err = tx.deleteTimeEntry(ctx, entry)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// Назначения пользователей на задачу
+		assignments, err := tx.findAssignments(ctx, Eq("task_id", task.Id))
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+		for _, assignment := range assignments {
+			err = tx.storage.Delete(ctx, TaskAssignmentCollection, assignment.Id)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// История статусов задачи
+		history, err := tx.findStatusChanges(ctx, Eq("task_id", task.Id))
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+		for _, change := range history {
+			err = tx.storage.Delete(ctx, TaskStatusHistoryCollection, change.Id)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+		}
+
+		// Удаление задачи
+		err = tx.storage.Delete(ctx, TaskCollection, task.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: DeleteTask task deleted", slog.Int("taskId", int(task.Id)), slog.Int("entries", len(entries)))
+		return nil
+	})
+}
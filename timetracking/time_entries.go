@@ -0,0 +1,636 @@
+package timetracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	. "timetracking/storage"
+)
+
+// Интервал работы пользователя над задачей
+type TimeEntry struct {
+	Id        int32      `json:"id" db:"id"`
+	TaskId    int32      `json:"taskId" db:"task_id"`             // идентификатор задачи
+	UserId    int32      `json:"userId" db:"user_id"`             // идентификатор пользователя
+	StartedAt time.Time  `json:"startedAt" db:"started_at"`       // начало работы
+	EndedAt   *time.Time `json:"endedAt,omitempty" db:"ended_at"` // конец работы, nil - работа идет
+	Note      string     `json:"note,omitempty" db:"note,null"`   // комментарий
+
+	AutoStopped bool  `json:"autoStopped" db:"auto_stopped,null"`       // остановлен автоматически, требует проверки
+	InvoiceId   int32 `json:"invoiceId,omitempty" db:"invoice_id,null"` // счет, в который вошел интервал, 0 - не оплачен
+
+	Created time.Time `json:"created" db:"created"` // дата создания
+
+	Pauses []*TimeEntryPause `json:"pauses,omitempty"` // паузы по порядку начала
+}
+
+// Пауза внутри интервала работы
+type TimeEntryPause struct {
+	Id        int32      `json:"id" db:"id"`
+	EntryId   int32      `json:"entryId" db:"entry_id"`           // идентификатор интервала работы
+	StartedAt time.Time  `json:"startedAt" db:"started_at"`       // начало паузы
+	EndedAt   *time.Time `json:"endedAt,omitempty" db:"ended_at"` // конец паузы, nil - пауза идет
+
+	Created time.Time `json:"created" db:"created"` // дата создания
+}
+
+// TimerState - состояние отсчета времени
+type TimerState string
+
+const (
+	TimerRunning TimerState = "running" // время идет
+	TimerPaused  TimerState = "paused"  // отсчет приостановлен
+	TimerStopped TimerState = "stopped" // отсчет не идет
+)
+
+// State - состояние отсчета времени интервала
+func (e *TimeEntry) State() TimerState {
+	if e.EndedAt != nil {
+		return TimerStopped
+	}
+	if e.openPause() != nil {
+		return TimerPaused
+	}
+	return TimerRunning
+}
+
+// mergeTimerState - общее состояние нескольких отсчетов: running важнее paused, paused важнее stopped
+func mergeTimerState(a, b TimerState) TimerState {
+	if a == TimerRunning || b == TimerRunning {
+		return TimerRunning
+	}
+	if a == TimerPaused || b == TimerPaused {
+		return TimerPaused
+	}
+	return TimerStopped
+}
+
+// openPause - незавершенная пауза интервала
+func (e *TimeEntry) openPause() *TimeEntryPause {
+	for _, pause := range e.Pauses {
+		if pause.EndedAt == nil {
+			return pause
+		}
+	}
+	return nil
+}
+
+// Время работы пользователя над задачей за период
+type TaskCost struct {
+	TaskId   int32      `json:"taskId"`   // идентификатор задачи
+	Title    string     `json:"title"`    // название задачи
+	Seconds  int64      `json:"seconds"`  // затраченное время в секундах
+	Duration string     `json:"duration"` // затраченное время, например "1h 05m 09s"
+	Running  bool       `json:"running"`  // время по задаче еще идет
+	Timer    TimerState `json:"timer"`    // состояние отсчета времени
+
+	spent time.Duration
+}
+
+// formatDuration - затраченное время с точностью до секунды: "1h 05m 09s"
+func formatDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	hours := d / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+	return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, seconds)
+}
+
+// DurationIn - время работы интервала внутри периода [begin, end] без пауз,
+// незавершенные интервал и пауза длятся до now
+func (e *TimeEntry) DurationIn(begin, end, now time.Time) time.Duration {
+	spent := overlap(e.StartedAt, e.EndedAt, begin, end, now)
+	for _, pause := range e.Pauses {
+		spent -= overlap(pause.StartedAt, pause.EndedAt, begin, end, now)
+	}
+	return max(spent, 0)
+}
+
+// overlap - длительность пересечения [from, to] с периодом [begin, end], to == nil - до now
+func overlap(from time.Time, to *time.Time, begin, end, now time.Time) time.Duration {
+	until := now
+	if to != nil {
+		until = *to
+	}
+
+	if from.Before(begin) {
+		from = begin
+	}
+	if until.After(end) {
+		until = end
+	}
+
+	if !until.After(from) {
+		return 0
+	}
+	return until.Sub(from)
+}
+
+// overlapsFilter - интервалы, пересекающиеся с периодом [begin, end]
+func overlapsFilter(begin, end time.Time) Filter {
+	return And(
+		Lte("started_at", end),
+		Or(IsNull("ended_at"), Gte("ended_at", begin)),
+	)
+}
+
+// Находит интервалы работы по фильтру
+func (s *TimeTrackingService) FindTimeEntriesByFilter(ctx context.Context, filter Filter, sort []Sort, page Pagination) ([]*TimeEntry, error) {
+	const op = "TimeTrackingService: FindTimeEntriesByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter), slog.Any("sort", sort), slog.Any("page", page))
+
+	filter, sort, err := paginate(filter, sort, page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получение интервалов по фильтру с сортировкой и пагинацией
+	reader, err := s.storage.Select(ctx, TimeEntryCollection, filter, sort, page.Limit, page.Offset)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	// Чтение интервалов
+	entries, err := ReadAll[TimeEntry](reader)
+	if err != nil {
+		return nil, processStorageError(op, err, true)
+	}
+
+	// Паузы интервалов
+	if len(entries) > 0 {
+		ids := make([]int32, len(entries))
+		byId := make(map[int32]*TimeEntry, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.Id
+			byId[entry.Id] = entry
+		}
+
+		reader, err := s.storage.Select(ctx, TimeEntryPauseCollection, In("entry_id", ids...), []Sort{{Field: "started_at"}, {Field: "id"}}, 0, 0)
+		if err != nil {
+			return nil, processStorageError(op, err, true)
+		}
+
+		pauses, err := ReadAll[TimeEntryPause](reader)
+		if err != nil {
+			return nil, processStorageError(op, err, true)
+		}
+
+		for _, pause := range pauses {
+			entry := byId[pause.EntryId]
+			entry.Pauses = append(entry.Pauses, pause)
+		}
+	}
+
+	Logger.Debug("TimeTrackingService: FindTimeEntriesByFilter entries found", slog.Int("count", len(entries)))
+	return entries, nil
+}
+
+// CountTimeEntriesByFilter - количество интервалов работы по фильтру
+func (s *TimeTrackingService) CountTimeEntriesByFilter(ctx context.Context, filter Filter) (int64, error) {
+	const op = "TimeTrackingService: CountTimeEntriesByFilter"
+
+	Logger.Debug(op, slog.Any("filter", filter))
+
+	count, err := s.storage.Count(ctx, TimeEntryCollection, filter)
+	if err != nil {
+		return 0, processStorageError(op, err, true)
+	}
+
+	Logger.Debug("TimeTrackingService: CountTimeEntriesByFilter entries counted", slog.Int64("count", count))
+	return count, nil
+}
+
+// openTimeEntry - незавершенный интервал пользователя по задаче, nil - задача пользователем не начата
+func (s *TimeTrackingService) openTimeEntry(ctx context.Context, taskId, userId int32) (*TimeEntry, error) {
+	filter := And(
+		Eq("task_id", taskId),
+		Eq("user_id", userId),
+		IsNull("ended_at"),
+	)
+	entries, err := s.FindTimeEntriesByFilter(ctx, filter, nil, Pagination{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries[0], nil
+}
+
+// deleteTimeEntry - удаление интервала работы вместе с его паузами
+func (s *TimeTrackingService) deleteTimeEntry(ctx context.Context, entry *TimeEntry) error {
+	for _, pause := range entry.Pauses {
+		if err := s.storage.Delete(ctx, TimeEntryPauseCollection, pause.Id); err != nil {
+			return err
+		}
+	}
+	return s.storage.Delete(ctx, TimeEntryCollection, entry.Id)
+}
+
+// FillTaskTimers - заполнить состояние отсчета времени задач по незавершенным интервалам работы
+func (s *TimeTrackingService) FillTaskTimers(ctx context.Context, tasks []*Task) error {
+	const op = "TimeTrackingService: FillTaskTimers"
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+
+	entries, err := s.FindTimeEntriesByFilter(ctx, And(In("task_id", ids...), IsNull("ended_at")), nil, Pagination{})
+	if err != nil {
+		return processStorageError(op, err, false)
+	}
+
+	timers := map[int32]TimerState{}
+	for _, entry := range entries {
+		timers[entry.TaskId] = mergeTimerState(timers[entry.TaskId], entry.State())
+	}
+
+	for _, task := range tasks {
+		task.Timer = mergeTimerState(timers[task.Id], TimerStopped)
+	}
+
+	return nil
+}
+
+// stopTimeEntry - закрытие интервала работы в момент at: незавершенная пауза закрывается,
+// время работы без пауз добавляется к общему времени задачи. Задача в работе откладывается,
+// когда закрыт ее последний незавершенный интервал
+func (s *TimeTrackingService) stopTimeEntry(ctx context.Context, entry *TimeEntry, at time.Time, autoStopped bool, note string) error {
+	if pause := entry.openPause(); pause != nil {
+		pauseEnd := at
+		if pauseEnd.Before(pause.StartedAt) {
+			pauseEnd = pause.StartedAt
+		}
+		err := s.storage.Update(ctx, TimeEntryPauseCollection, Match{"id": pause.Id}, map[string]any{"ended_at": pauseEnd})
+		if err != nil {
+			return err
+		}
+		pause.EndedAt = &pauseEnd
+	}
+
+	entryData := map[string]any{
+		"ended_at": at,
+	}
+	if autoStopped {
+		entryData["auto_stopped"] = true
+	}
+	if note != "" {
+		entryData["note"] = note
+	}
+	err := s.storage.Update(ctx, TimeEntryCollection, Match{"id": entry.Id}, entryData)
+	if err != nil {
+		return err
+	}
+	entry.EndedAt = &at
+
+	err = s.addTaskCost(ctx, entry.TaskId, entry.DurationIn(entry.StartedAt, at, at))
+	if err != nil {
+		return err
+	}
+
+	return s.pauseStoppedTask(ctx, entry.TaskId)
+}
+
+// maxTimeEntryNoteLength - ограничение длины комментария, совпадает с размером колонки
+const maxTimeEntryNoteLength = 500
+
+// Поля интервала работы для ручного создания и изменения, nil - поле не задано
+type TimeEntryData struct {
+	TaskId    *int32     `json:"taskId"`    // идентификатор задачи
+	StartedAt *time.Time `json:"startedAt"` // начало работы
+	EndedAt   *time.Time `json:"endedAt"`   // конец работы
+	Note      *string    `json:"note"`      // комментарий
+}
+
+// validate - проверка заданных полей, при создании обязательны задача, начало и конец
+func (d *TimeEntryData) validate(create bool) error {
+	if create {
+		switch {
+		case d.TaskId == nil:
+			return &InvalidError{"taskId is required"}
+		case d.StartedAt == nil:
+			return &InvalidError{"startedAt is required"}
+		case d.EndedAt == nil:
+			return &InvalidError{"endedAt is required"}
+		}
+	}
+
+	if d.Note != nil && utf8.RuneCountInString(*d.Note) > maxTimeEntryNoteLength {
+		return &InvalidError{fmt.Sprintf("note is longer than %d characters", maxTimeEntryNoteLength)}
+	}
+
+	return nil
+}
+
+// validateInterval - конец интервала позже начала и не в будущем
+func validateInterval(startedAt, endedAt, now time.Time) error {
+	if !endedAt.After(startedAt) {
+		return &InvalidError{"endedAt must be after startedAt"}
+	}
+	if endedAt.After(now) {
+		return &InvalidError{"endedAt is in the future"}
+	}
+	return nil
+}
+
+// checkOverlap - интервал [startedAt, endedAt] не должен пересекаться с другими интервалами пользователя,
+// интервалы, которые только касаются границами, допустимы
+func (s *TimeTrackingService) checkOverlap(ctx context.Context, userId, exceptId int32, startedAt, endedAt time.Time) error {
+	filter := And(
+		Eq("user_id", userId),
+		Neq("id", exceptId),
+		Lt("started_at", endedAt),
+		Or(IsNull("ended_at"), Gt("ended_at", startedAt)),
+	)
+	entries, err := s.FindTimeEntriesByFilter(ctx, filter, nil, Pagination{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return &InvalidError{fmt.Sprintf("interval overlaps time entry %d", entries[0].Id)}
+	}
+	return nil
+}
+
+// addTaskCost - изменение общего времени задачи на delta, задача блокируется до конца транзакции
+func (s *TimeTrackingService) addTaskCost(ctx context.Context, taskId int32, delta time.Duration) error {
+	tasks, err := s.FindTasksByFilter(ctx, Match{"id": taskId}, nil, Pagination{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	task := tasks[0]
+	cost := max(task.Cost+delta, 0)
+
+	// Предупреждение, когда время задачи впервые превышает оценку
+	if task.Estimate > 0 && task.Cost <= task.Estimate && cost > task.Estimate {
+		Logger.Warn("TimeTrackingService: task estimate exceeded", slog.Int("taskId", int(taskId)),
+			slog.Duration("estimate", task.Estimate), slog.Duration("cost", cost))
+	}
+
+	return s.storage.Update(ctx, TaskCollection, Match{"id": taskId}, map[string]any{
+		"cost": cost,
+	})
+}
+
+// findTimeEntryById - интервал работы по идентификатору
+func (s *TimeTrackingService) findTimeEntryById(ctx context.Context, op string, id int32) (*TimeEntry, error) {
+	entries, err := s.FindTimeEntriesByFilter(ctx, Match{"id": id}, nil, Pagination{Limit: 1})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+	if len(entries) == 0 {
+		Logger.Info(op+" failed", slog.String("error", "time entry not found"))
+		return nil, &NotFoundError{"time entry not found"}
+	}
+	return entries[0], nil
+}
+
+// Ручное создание завершенного интервала работы пользователя над задачей
+func (s *TimeTrackingService) CreateTimeEntry(ctx context.Context, pasportSeries, pasportNumber string, data TimeEntryData) (int32, error) {
+	const op = "TimeTrackingService: CreateTimeEntry"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber), slog.Any("data", data))
+
+	err := data.validate(true)
+	if err == nil {
+		err = validateInterval(*data.StartedAt, *data.EndedAt, time.Now())
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	startedAt, endedAt := data.StartedAt.UTC(), data.EndedAt.UTC()
+
+	var newId int32
+	err = s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		// Поиск пользователя по паспорту
+		user, err := tx.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+		if err != nil {
+			return processStorageError(op, err, false)
+		}
+
+		// Поиск задачи, задача блокируется до конца транзакции
+		task, err := tx.FindTaskById(ctx, *data.TaskId)
+		if err != nil {
+			return err
+		}
+		if err := checkTaskOpen(task); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		err = tx.checkOverlap(ctx, user.Id, 0, startedAt, endedAt)
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		err = tx.assign(ctx, task.Id, user.Id)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		entryData := map[string]any{
+			"task_id":      task.Id,
+			"user_id":      user.Id,
+			"started_at":   startedAt,
+			"ended_at":     endedAt,
+			"auto_stopped": false,
+		}
+		if data.Note != nil {
+			entryData["note"] = *data.Note
+		}
+		newId, err = tx.storage.Insert(ctx, TimeEntryCollection, entryData)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		err = tx.addTaskCost(ctx, task.Id, endedAt.Sub(startedAt))
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: CreateTimeEntry entry created", slog.Int("entryId", int(newId)), slog.Int("userId", int(user.Id)), slog.Int("taskId", int(task.Id)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newId, nil
+}
+
+// Ручное изменение завершенного интервала работы, меняются только заданные поля.
+// Измененный интервал считается проверенным и теряет отметку автоматической остановки
+func (s *TimeTrackingService) UpdateTimeEntry(ctx context.Context, id int32, data TimeEntryData) error {
+	const op = "TimeTrackingService: UpdateTimeEntry"
+
+	Logger.Debug(op, slog.Int("id", int(id)), slog.Any("data", data))
+
+	err := data.validate(false)
+	if err == nil && data.TaskId == nil && data.StartedAt == nil && data.EndedAt == nil && data.Note == nil {
+		err = &InvalidError{"nothing to update"}
+	}
+	if err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		entry, err := tx.findTimeEntryById(ctx, op, id)
+		if err != nil {
+			return err
+		}
+		if entry.EndedAt == nil {
+			Logger.Info(op+" failed", slog.String("error", "time entry is not ended"))
+			return &ConflictError{"time entry is not ended, end the task before editing"}
+		}
+		if err := checkNotInvoiced(entry); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+		if err := tx.checkEntryTaskOpen(ctx, op, entry); err != nil {
+			return err
+		}
+
+		// Новые границы интервала проверяются вместе с незаданными полями
+		startedAt, endedAt := entry.StartedAt, *entry.EndedAt
+		if data.StartedAt != nil {
+			startedAt = data.StartedAt.UTC()
+		}
+		if data.EndedAt != nil {
+			endedAt = data.EndedAt.UTC()
+		}
+		if err := validateInterval(startedAt, endedAt, time.Now()); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+		for _, pause := range entry.Pauses {
+			if pause.StartedAt.Before(startedAt) || pause.EndedAt != nil && pause.EndedAt.After(endedAt) {
+				Logger.Info(op+" failed", slog.String("error", "pause is outside the interval"))
+				return &InvalidError{"interval must contain its pauses"}
+			}
+		}
+
+		err = tx.checkOverlap(ctx, entry.UserId, entry.Id, startedAt, endedAt)
+		if err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+
+		// Перенос интервала на другую задачу
+		taskId := entry.TaskId
+		if data.TaskId != nil && *data.TaskId != entry.TaskId {
+			task, err := tx.FindTaskById(ctx, *data.TaskId)
+			if err != nil {
+				return err
+			}
+			if err := checkTaskOpen(task); err != nil {
+				Logger.Info(op+" failed", slog.String("error", err.Error()))
+				return err
+			}
+			err = tx.assign(ctx, task.Id, entry.UserId)
+			if err != nil {
+				return processStorageError(op, err, true)
+			}
+			taskId = task.Id
+		}
+
+		update := map[string]any{
+			"task_id":      taskId,
+			"started_at":   startedAt,
+			"ended_at":     endedAt,
+			"auto_stopped": false,
+		}
+		if data.Note != nil {
+			update["note"] = *data.Note
+		}
+		err = tx.storage.Update(ctx, TimeEntryCollection, Match{"id": entry.Id}, update)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		// Общее время задач: старое время интервала снимается, новое добавляется
+		oldTaskId := entry.TaskId
+		oldSpent := entry.DurationIn(entry.StartedAt, *entry.EndedAt, *entry.EndedAt)
+		entry.TaskId, entry.StartedAt, entry.EndedAt = taskId, startedAt, &endedAt
+		newSpent := entry.DurationIn(startedAt, endedAt, endedAt)
+
+		err = tx.addTaskCost(ctx, oldTaskId, -oldSpent)
+		if err == nil {
+			err = tx.addTaskCost(ctx, taskId, newSpent)
+		}
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: UpdateTimeEntry entry updated", slog.Int("entryId", int(entry.Id)))
+		return nil
+	})
+}
+
+// checkEntryTaskOpen - задача интервала не завершена и не отменена, ее время не меняется.
+// Задача блокируется до конца транзакции
+func (s *TimeTrackingService) checkEntryTaskOpen(ctx context.Context, op string, entry *TimeEntry) error {
+	task, err := s.FindTaskById(ctx, entry.TaskId)
+	if err != nil {
+		return err
+	}
+	if err := checkTaskOpen(task); err != nil {
+		Logger.Info(op+" failed", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Удаление завершенного интервала работы, время интервала снимается с задачи
+func (s *TimeTrackingService) DeleteTimeEntry(ctx context.Context, id int32) error {
+	const op = "TimeTrackingService: DeleteTimeEntry"
+
+	Logger.Debug(op, slog.Int("id", int(id)))
+
+	return s.withTx(ctx, op, func(tx *TimeTrackingService) error {
+		entry, err := tx.findTimeEntryById(ctx, op, id)
+		if err != nil {
+			return err
+		}
+		if entry.EndedAt == nil {
+			Logger.Info(op+" failed", slog.String("error", "time entry is not ended"))
+			return &ConflictError{"time entry is not ended, end the task before deleting"}
+		}
+		if err := checkNotInvoiced(entry); err != nil {
+			Logger.Info(op+" failed", slog.String("error", err.Error()))
+			return err
+		}
+		if err := tx.checkEntryTaskOpen(ctx, op, entry); err != nil {
+			return err
+		}
+
+		err = tx.deleteTimeEntry(ctx, entry)
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		err = tx.addTaskCost(ctx, entry.TaskId, -entry.DurationIn(entry.StartedAt, *entry.EndedAt, *entry.EndedAt))
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug("TimeTrackingService: DeleteTimeEntry entry deleted", slog.Int("entryId", int(entry.Id)), slog.Int("taskId", int(entry.TaskId)))
+		return nil
+	})
+}
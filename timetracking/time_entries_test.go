@@ -0,0 +1,154 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeEntryDurationIn(t *testing.T) {
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	ptr := func(t time.Time) *time.Time {
+		return &t
+	}
+	pause := func(from time.Time, to *time.Time) *TimeEntryPause {
+		return &TimeEntryPause{StartedAt: from, EndedAt: to}
+	}
+
+	tests := []struct {
+		name       string
+		entry      TimeEntry
+		begin, end time.Time
+		now        time.Time
+		want       time.Duration
+	}{
+		{
+			name:  "ended entry inside period",
+			entry: TimeEntry{StartedAt: at(10, 0), EndedAt: ptr(at(12, 0))},
+			begin: at(0, 0), end: at(23, 59), now: at(23, 0),
+			want: 2 * time.Hour,
+		},
+		{
+			name:  "running entry lasts until now",
+			entry: TimeEntry{StartedAt: at(10, 0)},
+			begin: at(0, 0), end: at(23, 59), now: at(11, 30),
+			want: 90 * time.Minute,
+		},
+		{
+			name:  "entry clipped by period",
+			entry: TimeEntry{StartedAt: at(8, 0), EndedAt: ptr(at(18, 0))},
+			begin: at(9, 0), end: at(12, 0), now: at(23, 0),
+			want: 3 * time.Hour,
+		},
+		{
+			name:  "entry outside period",
+			entry: TimeEntry{StartedAt: at(8, 0), EndedAt: ptr(at(9, 0))},
+			begin: at(10, 0), end: at(12, 0), now: at(23, 0),
+			want: 0,
+		},
+		{
+			name: "ended pauses are subtracted",
+			entry: TimeEntry{StartedAt: at(10, 0), EndedAt: ptr(at(14, 0)), Pauses: []*TimeEntryPause{
+				pause(at(11, 0), ptr(at(11, 30))),
+				pause(at(12, 0), ptr(at(13, 0))),
+			}},
+			begin: at(0, 0), end: at(23, 59), now: at(23, 0),
+			want: 150 * time.Minute,
+		},
+		{
+			name: "open pause lasts until now",
+			entry: TimeEntry{StartedAt: at(10, 0), Pauses: []*TimeEntryPause{
+				pause(at(11, 0), nil),
+			}},
+			begin: at(0, 0), end: at(23, 59), now: at(12, 0),
+			want: time.Hour,
+		},
+		{
+			name: "pause clipped by period",
+			entry: TimeEntry{StartedAt: at(8, 0), EndedAt: ptr(at(16, 0)), Pauses: []*TimeEntryPause{
+				pause(at(9, 0), ptr(at(11, 0))),
+			}},
+			begin: at(10, 0), end: at(12, 0), now: at(23, 0),
+			want: time.Hour,
+		},
+		{
+			name: "pause outside period",
+			entry: TimeEntry{StartedAt: at(8, 0), EndedAt: ptr(at(16, 0)), Pauses: []*TimeEntryPause{
+				pause(at(14, 0), ptr(at(15, 0))),
+			}},
+			begin: at(10, 0), end: at(12, 0), now: at(23, 0),
+			want: 2 * time.Hour,
+		},
+		{
+			name: "paused whole period",
+			entry: TimeEntry{StartedAt: at(8, 0), Pauses: []*TimeEntryPause{
+				pause(at(9, 0), nil),
+			}},
+			begin: at(10, 0), end: at(12, 0), now: at(13, 0),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.entry.DurationIn(tt.begin, tt.end, tt.now)
+			if got != tt.want {
+				t.Errorf("DurationIn() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeEntryOfClosedTask(t *testing.T) {
+	ctx := context.Background()
+	s, closedId := newTestTask(t, TaskTodo)
+
+	title := "Открытая задача"
+	openId, err := s.CreateTask(ctx, TaskData{Title: &title})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if _, err := s.CreateUser(ctx, "1234", "567890"); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	startedAt := time.Now().Add(-2 * time.Hour).UTC()
+	endedAt := startedAt.Add(time.Hour)
+	entryId, err := s.CreateTimeEntry(ctx, "1234", "567890", TimeEntryData{TaskId: &closedId, StartedAt: &startedAt, EndedAt: &endedAt})
+	if err != nil {
+		t.Fatalf("CreateTimeEntry() error = %v", err)
+	}
+	if err := s.ChangeTaskStatus(ctx, closedId, TaskStatusData{Status: TaskDone}); err != nil {
+		t.Fatalf("ChangeTaskStatus() error = %v", err)
+	}
+
+	note := "заметка"
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "edit", call: func() error { return s.UpdateTimeEntry(ctx, entryId, TimeEntryData{Note: &note}) }},
+		{name: "move to open task", call: func() error { return s.UpdateTimeEntry(ctx, entryId, TimeEntryData{TaskId: &openId}) }},
+		{name: "delete", call: func() error { return s.DeleteTimeEntry(ctx, entryId) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, &ConflictError{}) {
+				t.Errorf("error = %v, want ConflictError", err)
+			}
+		})
+	}
+
+	task, err := s.FindTaskById(ctx, closedId)
+	if err != nil {
+		t.Fatalf("FindTaskById() error = %v", err)
+	}
+	if task.Cost != time.Hour {
+		t.Errorf("cost of done task = %s, want %s", task.Cost, time.Hour)
+	}
+}
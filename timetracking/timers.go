@@ -0,0 +1,157 @@
+package timetracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	. "timetracking/storage"
+)
+
+// TimerPolicy - что делать, если пользователь начинает задачу, пока идет его таймер по другой задаче
+type TimerPolicy string
+
+const (
+	TimerPolicyReject TimerPolicy = "reject" // отказ с ошибкой конфликта
+	TimerPolicySwitch TimerPolicy = "switch" // идущий таймер останавливается автоматически
+)
+
+// ParseTimerPolicy - политика по названию
+func ParseTimerPolicy(s string) (TimerPolicy, error) {
+	switch policy := TimerPolicy(s); policy {
+	case TimerPolicyReject, TimerPolicySwitch:
+		return policy, nil
+	}
+	return "", &InvalidError{fmt.Sprintf("unknown timer policy %q, expected %s or %s", s, TimerPolicyReject, TimerPolicySwitch)}
+}
+
+// WithTimerPolicy - общая политика одного таймера, пользователь может задать свою
+func WithTimerPolicy(policy TimerPolicy) Option {
+	return func(s *TimeTrackingService) {
+		s.timerPolicy = policy
+	}
+}
+
+// userTimerPolicy - политика пользователя, если задана, иначе общая
+func (s *TimeTrackingService) userTimerPolicy(user *User) TimerPolicy {
+	if user.TimerPolicy != "" {
+		return TimerPolicy(user.TimerPolicy)
+	}
+	return s.timerPolicy
+}
+
+// ensureSingleTimer - у пользователя идет не больше одного таймера: перед запуском таймера по задаче taskId
+// идущие таймеры по другим задачам останавливаются или запуск отклоняется, по политике пользователя.
+// Приостановленные таймеры не мешают
+func (s *TimeTrackingService) ensureSingleTimer(ctx context.Context, op string, user *User, taskId int32) error {
+	filter := And(
+		Eq("user_id", user.Id),
+		Neq("task_id", taskId),
+		IsNull("ended_at"),
+	)
+	entries, err := s.FindTimeEntriesByFilter(ctx, filter, nil, Pagination{})
+	if err != nil {
+		return processStorageError(op, err, false)
+	}
+
+	policy := s.userTimerPolicy(user)
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		if entry.State() != TimerRunning {
+			continue
+		}
+
+		if policy != TimerPolicySwitch {
+			Logger.Info(op+" failed", slog.String("error", "another timer is running"), slog.Int("taskId", int(entry.TaskId)))
+			return &ConflictError{fmt.Sprintf("timer for task %d is running, end or pause it first", entry.TaskId)}
+		}
+
+		err = s.stopTimeEntry(ctx, entry, now, false, "")
+		if err != nil {
+			return processStorageError(op, err, true)
+		}
+
+		Logger.Debug(op+": previous timer stopped", slog.Int("entryId", int(entry.Id)), slog.Int("taskId", int(entry.TaskId)))
+	}
+
+	return nil
+}
+
+// Таймер пользователя по задаче
+type CurrentTimer struct {
+	EntryId   int32      `json:"entryId"`        // идентификатор интервала работы
+	TaskId    int32      `json:"taskId"`         // идентификатор задачи
+	Title     string     `json:"title"`          // название задачи
+	StartedAt time.Time  `json:"startedAt"`      // начало интервала
+	Timer     TimerState `json:"timer"`          // состояние отсчета времени
+	Seconds   int64      `json:"seconds"`        // время интервала без пауз в секундах
+	Duration  string     `json:"duration"`       // время интервала без пауз, например "1h 05m 09s"
+	Note      string     `json:"note,omitempty"` // комментарий
+}
+
+// Таймеры пользователя
+type CurrentTimers struct {
+	Current *CurrentTimer   `json:"current"` // идущий таймер, null - пользователь сейчас не работает
+	Paused  []*CurrentTimer `json:"paused"`  // приостановленные таймеры
+}
+
+// Текущий таймер пользователя и его приостановленные таймеры
+func (s *TimeTrackingService) CurrentTimersForUser(ctx context.Context, pasportSeries, pasportNumber string) (*CurrentTimers, error) {
+	const op = "TimeTrackingService: CurrentTimersForUser"
+
+	Logger.Debug(op, slog.String("pasportSeries", pasportSeries), slog.String("pasportNumber", pasportNumber))
+
+	user, err := s.FindUserByPassport(ctx, pasportSeries, pasportNumber)
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	filter := And(Eq("user_id", user.Id), IsNull("ended_at"))
+	entries, err := s.FindTimeEntriesByFilter(ctx, filter, []Sort{{Field: "started_at", Desc: true}, {Field: "id", Desc: true}}, Pagination{})
+	if err != nil {
+		return nil, processStorageError(op, err, false)
+	}
+
+	// Названия задач
+	taskIds := make([]int32, 0, len(entries))
+	for _, entry := range entries {
+		taskIds = append(taskIds, entry.TaskId)
+	}
+	titles := map[int32]string{}
+	if len(taskIds) > 0 {
+		tasks, err := s.FindTasksByFilter(ctx, In("id", taskIds...), nil, Pagination{})
+		if err != nil {
+			return nil, processStorageError(op, err, false)
+		}
+		for _, task := range tasks {
+			titles[task.Id] = task.Title
+		}
+	}
+
+	result := &CurrentTimers{Paused: []*CurrentTimer{}}
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		spent := entry.DurationIn(entry.StartedAt, now, now)
+		timer := &CurrentTimer{
+			EntryId:   entry.Id,
+			TaskId:    entry.TaskId,
+			Title:     titles[entry.TaskId],
+			StartedAt: entry.StartedAt,
+			Timer:     entry.State(),
+			Seconds:   int64(spent / time.Second),
+			Duration:  formatDuration(spent),
+			Note:      entry.Note,
+		}
+
+		// Если таймеров несколько (до появления политики), текущим считается начатый последним
+		if timer.Timer == TimerRunning && result.Current == nil {
+			result.Current = timer
+		} else if timer.Timer == TimerPaused {
+			result.Paused = append(result.Paused, timer)
+		}
+	}
+
+	Logger.Debug("TimeTrackingService: CurrentTimersForUser timers found", slog.Int("userId", int(user.Id)), slog.Int("open", len(entries)))
+	return result, nil
+}